@@ -6,14 +6,15 @@ require (
 	github.com/outofforest/build/v2 v2.4.0
 	github.com/outofforest/libexec v0.3.9
 	github.com/outofforest/logger v0.5.5
+	github.com/outofforest/parallel v0.2.3
 	github.com/pkg/errors v0.9.1
 	github.com/samber/lo v1.47.0
 	go.uber.org/zap v1.27.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
 	github.com/outofforest/ioc/v2 v2.5.2 // indirect
-	github.com/outofforest/parallel v0.2.3 // indirect
 	github.com/outofforest/run v0.8.0 // indirect
 	github.com/spf13/pflag v1.0.5 // indirect
 	github.com/ulikunitz/xz v0.5.12 // indirect
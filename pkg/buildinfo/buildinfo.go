@@ -0,0 +1,73 @@
+package buildinfo
+
+import (
+	"runtime/debug"
+	"time"
+)
+
+// Values of those variables are set by golang.Build using -X linker flags.
+var (
+	version   string
+	gitCommit string
+	buildTime string
+	dirty     string
+)
+
+// Info contains build metadata of the binary.
+type Info struct {
+	// Version is the version of the binary, taken from git tags.
+	Version string
+
+	// GitCommit is the hash of git commit the binary was built from.
+	GitCommit string
+
+	// BuildTime is the time of git commit the binary was built from.
+	BuildTime time.Time
+
+	// Dirty is true if binary was built from repository containing uncommitted changes.
+	Dirty bool
+}
+
+// Get returns build metadata of the binary. If metadata was not embedded by golang.Build,
+// values recorded by the go toolchain are used.
+func Get() Info {
+	info := Info{
+		Version:   version,
+		GitCommit: gitCommit,
+		Dirty:     dirty == "true",
+	}
+	if t, err := time.Parse(time.RFC3339, buildTime); err == nil {
+		info.BuildTime = t
+	}
+	if info.Version != "" {
+		return info
+	}
+
+	info.Version = "devel"
+	bi, ok := debug.ReadBuildInfo()
+	if !ok {
+		return info
+	}
+	if bi.Main.Version != "" && bi.Main.Version != "(devel)" {
+		info.Version = bi.Main.Version
+	}
+	for _, s := range bi.Settings {
+		switch s.Key {
+		case "vcs.revision":
+			info.GitCommit = s.Value
+		case "vcs.time":
+			if t, err := time.Parse(time.RFC3339, s.Value); err == nil {
+				info.BuildTime = t
+			}
+		case "vcs.modified":
+			info.Dirty = s.Value == "true"
+		}
+	}
+
+	return info
+}
+
+// Version returns the version of the binary.
+func Version() string {
+	return Get().Version
+}
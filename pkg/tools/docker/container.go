@@ -0,0 +1,87 @@
+package docker
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	"github.com/outofforest/libexec"
+	"github.com/outofforest/logger"
+)
+
+const containerRemoveTimeout = 30 * time.Second
+
+// ContainerName returns unique name of the container, so many builds might run concurrently on the same host.
+func ContainerName(prefix string) string {
+	suffix := make([]byte, 6)
+	if _, err := rand.Read(suffix); err != nil {
+		panic(errors.WithStack(err))
+	}
+	return prefix + "-" + hex.EncodeToString(suffix)
+}
+
+// RunContainer executes docker run command starting the container of the given name. Command must not be
+// started yet, --cidfile flag is added to it to track the created container. If command fails, container
+// is removed, because e.g. killing docker client on context cancellation doesn't stop the container.
+func RunContainer(ctx context.Context, name string, cmd *exec.Cmd) error {
+	if len(cmd.Args) < 2 || cmd.Args[1] != "run" {
+		return errors.Errorf("command '%s' doesn't run docker container", cmd)
+	}
+
+	cidDir, err := os.MkdirTemp("", "docker-cid-*")
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer os.RemoveAll(cidDir)
+
+	// Docker stores ID of the container in the file once the container is created.
+	cidFile := filepath.Join(cidDir, "cid")
+	cmd.Args = append([]string{cmd.Args[0], "run", "--cidfile", cidFile}, cmd.Args[2:]...)
+
+	err = libexec.Exec(ctx, cmd)
+	if err == nil {
+		return nil
+	}
+	if rmErr := removeContainer(ctx, name, cidFile); rmErr != nil {
+		logger.Get(ctx).Warn("Removing container failed", zap.String("container", name), zap.Error(rmErr))
+	}
+	return err
+}
+
+// removeContainer removes the container identified by ID stored in the file. If docker client exited before
+// the ID was stored, container is removed by name, because daemon might have created it anyway.
+func removeContainer(ctx context.Context, name, cidFile string) error {
+	container := name
+	cid, err := os.ReadFile(cidFile)
+	switch {
+	case err == nil && len(bytes.TrimSpace(cid)) > 0:
+		container = string(bytes.TrimSpace(cid))
+	case err != nil && !os.IsNotExist(err):
+		return errors.WithStack(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), containerRemoveTimeout)
+	defer cancel()
+
+	stderr := &bytes.Buffer{}
+	cmd := exec.Command("docker", "rm", "--force", container)
+	cmd.Stdout = &bytes.Buffer{}
+	cmd.Stderr = stderr
+	if err := libexec.Exec(ctx, cmd); err != nil {
+		// Container is not created if docker run fails early, e.g. when image can't be pulled.
+		if strings.Contains(stderr.String(), "No such container") {
+			return nil
+		}
+		return errors.Wrapf(err, "removing container failed: %s", stderr)
+	}
+	return nil
+}
@@ -0,0 +1,210 @@
+package docker
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/samber/lo"
+	"go.uber.org/zap"
+
+	"github.com/outofforest/build/v2/pkg/tools"
+	builddocker "github.com/outofforest/build/v2/pkg/tools/docker"
+	"github.com/outofforest/build/v2/pkg/types"
+	"github.com/outofforest/libexec"
+	"github.com/outofforest/logger"
+)
+
+// ToolLabelKey is the label of images built for particular version of the tool. Its value is in the form
+// <tool>@<version>.
+const ToolLabelKey = "com.outofforest.build.tool"
+
+// ToolLabel returns value of the tool label.
+func ToolLabel(tool tools.Name, version string) string {
+	return string(tool) + "@" + version
+}
+
+// PruneConfig is the configuration for removing stale docker resources.
+type PruneConfig struct {
+	// DryRun lists resources which would be removed without removing them.
+	DryRun bool
+
+	// InUseImages are the references of builder images currently used. Other images created by the build
+	// in their repositories are stale, e.g. the ones rendered from outdated Dockerfiles or built before images
+	// were labelled with tool versions.
+	InUseImages []string
+}
+
+type pruneImage struct {
+	ID     string
+	Refs   []string
+	Reason string
+}
+
+// Prune removes exited build containers and images built for tool versions which are no longer used.
+// Images without tool label, e.g. the ones produced by the build, are removed only if they belong
+// to repositories of images in use. Go builder images are pruned by golang.PruneDocker.
+func Prune(ctx context.Context, deps types.DepsFunc) error {
+	return prune(ctx, deps, PruneConfig{})
+}
+
+// PruneWithConfig returns command removing stale docker resources using provided config.
+func PruneWithConfig(config PruneConfig) types.CommandFunc {
+	return func(ctx context.Context, deps types.DepsFunc) error {
+		return prune(ctx, deps, config)
+	}
+}
+
+func prune(ctx context.Context, deps types.DepsFunc, config PruneConfig) error {
+	deps(builddocker.EnsureDocker)
+
+	log := logger.Get(ctx)
+	labelFilter := "label=" + builddocker.LabelKey + "=" + builddocker.LabelValue
+
+	// Created containers are not removed, because they might be just started by concurrent build.
+	containersOutput, err := dockerOutput(ctx, "ps", "--all", "--no-trunc",
+		"--filter", labelFilter,
+		"--filter", "status=exited",
+		"--filter", "status=dead",
+		"--format", "{{.ID}}\t{{.Names}}\t{{.Status}}")
+	if err != nil {
+		return err
+	}
+	var containers []string
+	for _, line := range nonEmptyLines(containersOutput) {
+		fields := strings.SplitN(line, "\t", 3)
+		containers = append(containers, fields[0])
+		if _, err := fmt.Fprintf(os.Stdout, "container %s\n", strings.Join(fields[1:], "\t")); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+
+	images, err := staleImages(ctx, labelFilter, config.InUseImages)
+	if err != nil {
+		return err
+	}
+	for _, image := range images {
+		name := image.ID
+		if len(image.Refs) > 0 {
+			name = strings.Join(image.Refs, ", ")
+		}
+		if _, err := fmt.Fprintf(os.Stdout, "image %s\t%s\n", name, image.Reason); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+
+	if config.DryRun {
+		log.Info("Dry run, nothing removed",
+			zap.Int("containers", len(containers)), zap.Int("images", len(images)))
+		return nil
+	}
+
+	if len(containers) > 0 {
+		if _, err := dockerOutput(ctx, append([]string{"rm"}, containers...)...); err != nil {
+			return err
+		}
+	}
+	for _, image := range images {
+		// Image is removed by references, because removing image having many tags by ID fails.
+		refs := image.Refs
+		if len(refs) == 0 {
+			refs = []string{image.ID}
+		}
+		if _, err := dockerOutput(ctx, append([]string{"rmi"}, refs...)...); err != nil {
+			return err
+		}
+	}
+
+	log.Info("Docker resources removed", zap.Int("containers", len(containers)), zap.Int("images", len(images)))
+	return nil
+}
+
+// staleImages returns images built for versions of tools which are not used anymore and images which belong
+// to repositories of images in use but are not used themselves.
+func staleImages(ctx context.Context, labelFilter string, inUseImages []string) ([]pruneImage, error) {
+	idsOutput, err := dockerOutput(ctx, "images", "--quiet", "--no-trunc", "--filter", labelFilter)
+	if err != nil {
+		return nil, err
+	}
+	ids := nonEmptyLines(idsOutput)
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	inspectOutput, err := dockerOutput(ctx, append([]string{"image", "inspect", "--format",
+		`{{.Id}}{{"\t"}}{{index .Config.Labels "` + ToolLabelKey + `"}}{{"\t"}}{{join .RepoTags " "}}`},
+		lo.Uniq(ids)...)...)
+	if err != nil {
+		return nil, err
+	}
+
+	inUseRepositories := lo.Map(inUseImages, func(ref string, _ int) string {
+		return imageRepository(ref)
+	})
+
+	var images []pruneImage
+	for _, line := range nonEmptyLines(inspectOutput) {
+		fields := strings.SplitN(line, "\t", 3)
+		if len(fields) < 2 {
+			continue
+		}
+		image := pruneImage{
+			ID: fields[0],
+		}
+		if len(fields) == 3 {
+			image.Refs = strings.Fields(fields[2])
+		}
+
+		if toolName, version, ok := strings.Cut(fields[1], "@"); ok {
+			// If tool is not known to this builder, it can't be decided if image is stale.
+			if tool, err := tools.Get(tools.Name(toolName)); err == nil && tool.GetVersion() != version {
+				image.Reason = fields[1] + " is not used"
+				images = append(images, image)
+				continue
+			}
+		}
+
+		if lo.Some(inUseImages, image.Refs) {
+			continue
+		}
+		if lo.ContainsBy(image.Refs, func(ref string) bool {
+			return lo.Contains(inUseRepositories, imageRepository(ref))
+		}) {
+			image.Reason = "not used"
+			images = append(images, image)
+		}
+	}
+	return images, nil
+}
+
+// imageRepository returns the repository part of the image reference.
+func imageRepository(ref string) string {
+	if i := strings.LastIndex(ref, ":"); i > strings.LastIndex(ref, "/") {
+		return ref[:i]
+	}
+	return ref
+}
+
+func dockerOutput(ctx context.Context, args ...string) (string, error) {
+	buf := &bytes.Buffer{}
+	cmd := exec.Command("docker", args...)
+	cmd.Stdout = buf
+	if err := libexec.Exec(ctx, cmd); err != nil {
+		return "", errors.Wrapf(err, "docker command '%s' failed", strings.Join(args, " "))
+	}
+	return buf.String(), nil
+}
+
+func nonEmptyLines(s string) []string {
+	var lines []string
+	for _, line := range strings.Split(s, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
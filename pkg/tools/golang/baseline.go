@@ -0,0 +1,231 @@
+package golang
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/pkg/errors"
+	"github.com/samber/lo"
+	"go.uber.org/zap"
+
+	"github.com/outofforest/build/v2/pkg/types"
+	"github.com/outofforest/logger"
+)
+
+// LintBaselineFile is the name of the file, stored in module directory, containing linter findings accepted
+// in the module. Only findings not present in the baseline cause linter to fail.
+const LintBaselineFile = ".golangci-baseline.json"
+
+// lintBaselineEntry identifies the finding. Line numbers are not included because they change whenever
+// code above the finding is modified.
+type lintBaselineEntry struct {
+	Linter string `json:"linter"`
+	File   string `json:"file"`
+	Text   string `json:"text"`
+}
+
+// LintBaseline records current linter findings of each module in the baseline file.
+func LintBaseline(ctx context.Context, deps types.DepsFunc) error {
+	return lintBaseline(ctx, deps, LintConfig{})
+}
+
+// LintBaselineWithConfig returns command recording linter findings using provided config. Workspace mode
+// must match the one used by the linter, otherwise findings might differ.
+func LintBaselineWithConfig(config LintConfig) types.CommandFunc {
+	return func(ctx context.Context, deps types.DepsFunc) error {
+		return lintBaseline(ctx, deps, config)
+	}
+}
+
+func lintBaseline(ctx context.Context, deps types.DepsFunc, config LintConfig) error {
+	deps(EnsureGo, EnsureGolangCI, storeLintConfig)
+
+	repoDir := lo.Must(filepath.Abs("."))
+	envs, err := workspaceEnv(ctx, config.Workspace)
+	if err != nil {
+		return err
+	}
+
+	return onModules(ctx, "go.mod", config.Workers, func(ctx context.Context, path string, output io.Writer) error {
+		goCodePresent, err := containsGoCode(path)
+		if err != nil {
+			return err
+		}
+		if !goCodePresent {
+			return nil
+		}
+
+		absPath := lo.Must(filepath.Abs(path))
+		issues, err := moduleLintIssues(ctx, repoDir, absPath, envs, output)
+		if err != nil {
+			return err
+		}
+
+		entries := lo.Map(issues, func(issue lintIssue, _ int) lintBaselineEntry {
+			return newLintBaselineEntry(absPath, issue)
+		})
+		if err := storeLintBaseline(absPath, entries); err != nil {
+			return err
+		}
+
+		logger.Get(ctx).Info("Linter baseline stored", zap.String("path", path), zap.Int("findings", len(entries)))
+		return nil
+	})
+}
+
+// PruneLintBaseline removes findings which are no longer reported from baseline files.
+func PruneLintBaseline(ctx context.Context, deps types.DepsFunc) error {
+	return pruneLintBaseline(ctx, deps, LintConfig{})
+}
+
+// PruneLintBaselineWithConfig returns command removing findings which are no longer reported from baseline
+// files using provided config.
+func PruneLintBaselineWithConfig(config LintConfig) types.CommandFunc {
+	return func(ctx context.Context, deps types.DepsFunc) error {
+		return pruneLintBaseline(ctx, deps, config)
+	}
+}
+
+func pruneLintBaseline(ctx context.Context, deps types.DepsFunc, config LintConfig) error {
+	deps(EnsureGo, EnsureGolangCI, storeLintConfig)
+
+	repoDir := lo.Must(filepath.Abs("."))
+	envs, err := workspaceEnv(ctx, config.Workspace)
+	if err != nil {
+		return err
+	}
+
+	return onModules(ctx, "go.mod", config.Workers, func(ctx context.Context, path string, output io.Writer) error {
+		absPath := lo.Must(filepath.Abs(path))
+		baseline, err := readLintBaseline(absPath)
+		if err != nil {
+			return err
+		}
+		if baseline == nil {
+			return nil
+		}
+
+		issues, err := moduleLintIssues(ctx, repoDir, absPath, envs, output)
+		if err != nil {
+			return err
+		}
+
+		reported := map[lintBaselineEntry]int{}
+		for _, issue := range issues {
+			reported[newLintBaselineEntry(absPath, issue)]++
+		}
+		entries := make([]lintBaselineEntry, 0, len(baseline))
+		for _, entry := range baseline {
+			if reported[entry] > 0 {
+				reported[entry]--
+				entries = append(entries, entry)
+			}
+		}
+		if err := storeLintBaseline(absPath, entries); err != nil {
+			return err
+		}
+
+		logger.Get(ctx).Info("Linter baseline pruned", zap.String("path", path),
+			zap.Int("removed", len(baseline)-len(entries)), zap.Int("findings", len(entries)))
+		return nil
+	})
+}
+
+// moduleLintIssues returns all the issues reported by linter in the module.
+func moduleLintIssues(
+	ctx context.Context,
+	repoDir, modulePath string,
+	envs []string,
+	output io.Writer,
+) ([]lintIssue, error) {
+	lintConfig, err := moduleLintConfigPath(ctx, repoDir, modulePath)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Get(ctx).Info("Running linter", zap.String("path", modulePath))
+	return collectLintIssues(ctx, modulePath, []string{"run", "--config", lintConfig, "--allow-parallel-runners"},
+		envs, output)
+}
+
+// newLintIssues returns issues not present in the baseline. Each baseline entry accepts one issue.
+func newLintIssues(modulePath string, baseline []lintBaselineEntry, issues []lintIssue) []lintIssue {
+	accepted := map[lintBaselineEntry]int{}
+	for _, entry := range baseline {
+		accepted[entry]++
+	}
+
+	result := make([]lintIssue, 0, len(issues))
+	for _, issue := range issues {
+		entry := newLintBaselineEntry(modulePath, issue)
+		if accepted[entry] > 0 {
+			accepted[entry]--
+			continue
+		}
+		result = append(result, issue)
+	}
+	return result
+}
+
+func newLintBaselineEntry(modulePath string, issue lintIssue) lintBaselineEntry {
+	file := issue.Pos.Filename
+	if filepath.IsAbs(file) {
+		if relFile, err := filepath.Rel(modulePath, file); err == nil {
+			file = relFile
+		}
+	}
+	return lintBaselineEntry{
+		Linter: issue.FromLinter,
+		File:   filepath.ToSlash(file),
+		Text:   issue.Text,
+	}
+}
+
+// readLintBaseline reads baseline of the module. Nil is returned if module has no baseline.
+func readLintBaseline(modulePath string) ([]lintBaselineEntry, error) {
+	file := filepath.Join(modulePath, LintBaselineFile)
+	content, err := os.ReadFile(file)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, errors.WithStack(err)
+	}
+
+	entries := []lintBaselineEntry{}
+	if err := json.Unmarshal(content, &entries); err != nil {
+		return nil, errors.Wrapf(err, "parsing linter baseline '%s' failed", file)
+	}
+	return entries, nil
+}
+
+// storeLintBaseline stores baseline of the module. File is removed if there are no entries.
+func storeLintBaseline(modulePath string, entries []lintBaselineEntry) error {
+	file := filepath.Join(modulePath, LintBaselineFile)
+	if len(entries) == 0 {
+		if err := os.Remove(file); err != nil && !os.IsNotExist(err) {
+			return errors.WithStack(err)
+		}
+		return nil
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		if entries[i].File != entries[j].File {
+			return entries[i].File < entries[j].File
+		}
+		if entries[i].Linter != entries[j].Linter {
+			return entries[i].Linter < entries[j].Linter
+		}
+		return entries[i].Text < entries[j].Text
+	})
+
+	content, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	return errors.WithStack(os.WriteFile(file, append(content, '\n'), 0o600))
+}
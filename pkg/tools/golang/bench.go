@@ -0,0 +1,404 @@
+package golang
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"math"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/pkg/errors"
+	"github.com/samber/lo"
+	"go.uber.org/zap"
+
+	"github.com/outofforest/build/v2/pkg/helpers"
+	"github.com/outofforest/build/v2/pkg/tools"
+	"github.com/outofforest/build/v2/pkg/types"
+	"github.com/outofforest/libexec"
+	"github.com/outofforest/logger"
+)
+
+const (
+	benchBaselineDir = "baseline"
+	benchResultsFile = "bench.txt"
+)
+
+// BenchConfig is the configuration for running benchmarks.
+type BenchConfig struct {
+	// Bench is the regular expression selecting benchmarks to run.
+	Bench string
+
+	// Count is the number of times each benchmark is executed. Many samples are required to detect
+	// statistically significant changes.
+	Count int
+
+	// BenchTime is the time spent on running each benchmark, e.g. "5s" or "100x".
+	BenchTime string
+
+	// Tags is go build tags.
+	Tags []string
+
+	// MaxRegression is the maximum accepted regression of benchmark metric, in percents.
+	MaxRegression float64
+
+	// Alpha is the significance level. Changes with p-value greater than alpha are ignored.
+	Alpha float64
+}
+
+// DefaultBenchConfig returns the default configuration for running benchmarks.
+func DefaultBenchConfig() BenchConfig {
+	return BenchConfig{
+		Bench:         ".",
+		Count:         10,
+		Tags:          []string{"testing"},
+		MaxRegression: 10,
+		Alpha:         0.05,
+	}
+}
+
+// Bench runs go benchmarks and compares results with the baseline.
+func Bench(ctx context.Context, deps types.DepsFunc) error {
+	return bench(ctx, deps, DefaultBenchConfig())
+}
+
+// BenchWithConfig returns command running go benchmarks using provided config.
+func BenchWithConfig(config BenchConfig) types.CommandFunc {
+	return func(ctx context.Context, deps types.DepsFunc) error {
+		return bench(ctx, deps, config)
+	}
+}
+
+// BenchBaseline runs go benchmarks and stores results as the baseline.
+func BenchBaseline(ctx context.Context, deps types.DepsFunc) error {
+	return benchBaseline(ctx, deps, DefaultBenchConfig())
+}
+
+// BenchBaselineWithConfig returns command storing benchmark baseline using provided config.
+func BenchBaselineWithConfig(config BenchConfig) types.CommandFunc {
+	return func(ctx context.Context, deps types.DepsFunc) error {
+		return benchBaseline(ctx, deps, config)
+	}
+}
+
+func bench(ctx context.Context, deps types.DepsFunc, config BenchConfig) error {
+	deps(EnsureGo)
+
+	resultsDir, err := runBenchmarks(ctx, config)
+	if err != nil {
+		return err
+	}
+
+	baselineDir := filepath.Join(benchDir(ctx), benchBaselineDir)
+	if _, err := os.Stat(baselineDir); err != nil {
+		if !os.IsNotExist(err) {
+			return errors.WithStack(err)
+		}
+		logger.Get(ctx).Info("No benchmark baseline, skipping comparison")
+		return nil
+	}
+	return compareBenchmarks(baselineDir, resultsDir, config)
+}
+
+func benchBaseline(ctx context.Context, deps types.DepsFunc, config BenchConfig) error {
+	deps(EnsureGo)
+
+	resultsDir, err := runBenchmarks(ctx, config)
+	if err != nil {
+		return err
+	}
+
+	// Results are copied, so running benchmarks on the same commit later doesn't overwrite the baseline.
+	baselineDir := filepath.Join(benchDir(ctx), benchBaselineDir)
+	logger.Get(ctx).Info("Storing benchmark baseline", zap.String("path", baselineDir))
+	if err := os.RemoveAll(baselineDir); err != nil {
+		return errors.WithStack(err)
+	}
+	return copyBenchmarks(baselineDir, resultsDir)
+}
+
+// runBenchmarks runs benchmarks in all the modules and returns the directory where results are stored.
+func runBenchmarks(ctx context.Context, config BenchConfig) (string, error) {
+	log := logger.Get(ctx)
+
+	info, err := getGitInfo(ctx, ".")
+	if err != nil {
+		return "", err
+	}
+	key := info.Commit
+	if info.Dirty {
+		key += "-dirty"
+	}
+	resultsDir := filepath.Join(benchDir(ctx), key)
+	if err := os.RemoveAll(resultsDir); err != nil {
+		return "", errors.WithStack(err)
+	}
+	if err := os.MkdirAll(resultsDir, 0o700); err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	rootDir := filepath.Dir(lo.Must(filepath.Abs(lo.Must(filepath.EvalSymlinks(lo.Must(os.Getwd()))))))
+	// Benchmarks running concurrently compete for CPU, so modules are processed sequentially.
+	err = helpers.OnModule("go.mod", func(path string) error {
+		path = lo.Must(filepath.Abs(lo.Must(filepath.EvalSymlinks(path))))
+		relPath, err := filepath.Rel(rootDir, path)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+
+		goCodePresent, err := containsGoCode(path)
+		if err != nil {
+			return err
+		}
+		if !goCodePresent {
+			log.Info("No code to benchmark", zap.String("path", path))
+			return nil
+		}
+
+		resultsPath := filepath.Join(resultsDir, relPath, benchResultsFile)
+		if err := os.MkdirAll(filepath.Dir(resultsPath), 0o700); err != nil {
+			return errors.WithStack(err)
+		}
+		resultsFile, err := os.OpenFile(resultsPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o600)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		defer resultsFile.Close()
+
+		args := []string{
+			"test",
+			"-run=^$",
+			"-bench=" + config.Bench,
+			"-benchmem",
+			"-count=" + strconv.Itoa(max(config.Count, 1)),
+		}
+		if config.BenchTime != "" {
+			args = append(args, "-benchtime="+config.BenchTime)
+		}
+		if len(config.Tags) != 0 {
+			args = append(args, "-tags="+strings.Join(config.Tags, ","))
+		}
+		args = append(args, "./...")
+
+		log.Info("Running go benchmarks", zap.String("path", path))
+		cmd := exec.Command(tools.Bin(ctx, "bin/go", tools.PlatformLocal), args...)
+		cmd.Env = env(ctx)
+		cmd.Dir = path
+		cmd.Stdout = io.MultiWriter(os.Stdout, resultsFile)
+		if err := libexec.Exec(ctx, cmd); err != nil {
+			return errors.Wrapf(err, "benchmarks failed in module '%s'", path)
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	log.Info("Benchmark results stored", zap.String("path", resultsDir))
+	return resultsDir, nil
+}
+
+func benchDir(ctx context.Context) string {
+	return filepath.Join(tools.DevDir(ctx), "go", "bench")
+}
+
+// copyBenchmarks copies benchmark results stored in the source directory to the destination one.
+func copyBenchmarks(dstDir, srcDir string) error {
+	return errors.WithStack(filepath.WalkDir(srcDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		if d.IsDir() {
+			return nil
+		}
+
+		dst := filepath.Join(dstDir, lo.Must(filepath.Rel(srcDir, path)))
+		if err := os.MkdirAll(filepath.Dir(dst), 0o700); err != nil {
+			return errors.WithStack(err)
+		}
+		return helpers.CopyFile(dst, path, 0o600)
+	}))
+}
+
+// benchSamples contains samples of benchmark metrics keyed by benchmark and unit.
+type benchSamples map[string]map[string][]float64
+
+func compareBenchmarks(baselineDir, resultsDir string, config BenchConfig) error {
+	baseline, err := readBenchmarks(baselineDir)
+	if err != nil {
+		return err
+	}
+	results, err := readBenchmarks(resultsDir)
+	if err != nil {
+		return err
+	}
+
+	regressions := []string{}
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "BENCHMARK\tUNIT\tBASELINE\tCURRENT\tDELTA\tP-VALUE")
+	for _, name := range sortedKeys(results) {
+		for _, unit := range sortedKeys(results[name]) {
+			old := baseline[name][unit]
+			if len(old) == 0 {
+				continue
+			}
+			current := results[name][unit]
+
+			oldMedian := median(old)
+			currentMedian := median(current)
+			p := mannWhitneyPValue(old, current)
+
+			delta := "~"
+			if p <= config.Alpha && oldMedian != 0 {
+				change := 100 * (currentMedian - oldMedian) / oldMedian
+				delta = fmt.Sprintf("%+.2f%%", change)
+
+				// For throughput metrics, like MB/s, higher values are better.
+				if strings.HasSuffix(unit, "/s") {
+					change = -change
+				}
+				if change > config.MaxRegression {
+					regressions = append(regressions, fmt.Sprintf("%s: %s changed by %s", name, unit, delta))
+				}
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%.4g\t%.4g\t%s\t%.3f\n", name, unit, oldMedian, currentMedian, delta, p)
+		}
+	}
+	if err := tw.Flush(); err != nil {
+		return errors.WithStack(err)
+	}
+
+	if len(regressions) > 0 {
+		return errors.Errorf("benchmark regressions exceeding %.1f%% detected:\n%s", config.MaxRegression,
+			strings.Join(regressions, "\n"))
+	}
+	return nil
+}
+
+// readBenchmarks reads benchmark results stored in the directory.
+func readBenchmarks(dir string) (benchSamples, error) {
+	samples := benchSamples{}
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		if d.IsDir() {
+			return nil
+		}
+
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		parseBenchmarks(content, samples)
+		return nil
+	})
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return samples, nil
+}
+
+// parseBenchmarks parses output of go benchmarks, lines have format:
+// BenchmarkName-8   1000000   1234 ns/op   120 B/op   3 allocs/op.
+func parseBenchmarks(content []byte, samples benchSamples) {
+	var pkg string
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if p, ok := strings.CutPrefix(line, "pkg: "); ok {
+			pkg = strings.TrimSpace(p)
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) < 4 || !strings.HasPrefix(fields[0], "Benchmark") || len(fields)%2 != 0 {
+			continue
+		}
+		if _, err := strconv.ParseInt(fields[1], 10, 64); err != nil {
+			continue
+		}
+
+		name := fields[0]
+		if pkg != "" {
+			name = pkg + "." + name
+		}
+		if samples[name] == nil {
+			samples[name] = map[string][]float64{}
+		}
+		for i := 2; i < len(fields); i += 2 {
+			value, err := strconv.ParseFloat(fields[i], 64)
+			if err != nil {
+				break
+			}
+			samples[name][fields[i+1]] = append(samples[name][fields[i+1]], value)
+		}
+	}
+}
+
+func median(values []float64) float64 {
+	sorted := append([]float64{}, values...)
+	sort.Float64s(sorted)
+	if len(sorted)%2 == 1 {
+		return sorted[len(sorted)/2]
+	}
+	return (sorted[len(sorted)/2-1] + sorted[len(sorted)/2]) / 2
+}
+
+// mannWhitneyPValue returns two-sided p-value of the Mann-Whitney U test, using normal approximation
+// corrected for ties.
+func mannWhitneyPValue(x, y []float64) float64 {
+	type sample struct {
+		value float64
+		x     bool
+	}
+
+	all := make([]sample, 0, len(x)+len(y))
+	for _, v := range x {
+		all = append(all, sample{value: v, x: true})
+	}
+	for _, v := range y {
+		all = append(all, sample{value: v})
+	}
+	sort.Slice(all, func(i, j int) bool {
+		return all[i].value < all[j].value
+	})
+
+	n1, n2, n := float64(len(x)), float64(len(y)), float64(len(all))
+	var rankSumX, tieCorrection float64
+	for i := 0; i < len(all); {
+		j := i
+		for j < len(all) && all[j].value == all[i].value {
+			j++
+		}
+		rank := float64(i+j+1) / 2
+		for k := i; k < j; k++ {
+			if all[k].x {
+				rankSumX += rank
+			}
+		}
+		t := float64(j - i)
+		tieCorrection += t*t*t - t
+		i = j
+	}
+
+	u := rankSumX - n1*(n1+1)/2
+	mean := n1 * n2 / 2
+	variance := n1 * n2 / 12 * ((n + 1) - tieCorrection/(n*(n-1)))
+	if variance <= 0 {
+		return 1
+	}
+	z := (math.Abs(u-mean) - 0.5) / math.Sqrt(variance)
+	if z < 0 {
+		return 1
+	}
+	return math.Erfc(z / math.Sqrt2)
+}
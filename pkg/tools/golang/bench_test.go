@@ -0,0 +1,141 @@
+package golang
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestMannWhitneyPValue(t *testing.T) {
+	tests := []struct {
+		name string
+		x    []float64
+		y    []float64
+		want float64
+	}{
+		{
+			name: "separated samples",
+			x:    []float64{1, 2, 3, 4, 5},
+			y:    []float64{6, 7, 8, 9, 10},
+			want: 0.012186,
+		},
+		{
+			name: "separated samples reversed",
+			x:    []float64{6, 7, 8, 9, 10},
+			y:    []float64{1, 2, 3, 4, 5},
+			want: 0.012186,
+		},
+		{
+			name: "identical samples",
+			x:    []float64{1, 2, 3, 4, 5},
+			y:    []float64{1, 2, 3, 4, 5},
+			want: 1,
+		},
+		{
+			name: "ties",
+			x:    []float64{1, 2, 2, 3, 10},
+			y:    []float64{2, 3, 4, 5, 6, 7},
+			want: 0.267814,
+		},
+		{
+			name: "overlapping samples",
+			x:    []float64{10, 11, 12, 13, 14, 15, 16, 17, 18, 19},
+			y:    []float64{12, 13, 14, 15, 16, 17, 18, 19, 20, 21},
+			want: 0.184551,
+		},
+		{
+			name: "all values equal",
+			x:    []float64{5, 5, 5},
+			y:    []float64{5, 5, 5},
+			want: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := mannWhitneyPValue(tt.x, tt.y); math.Abs(got-tt.want) > 1e-6 {
+				t.Errorf("got %f, want %f", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseBenchmarks(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    benchSamples
+	}{
+		{
+			name: "many packages",
+			content: `goos: linux
+goarch: amd64
+pkg: example.com/a
+cpu: Some CPU
+BenchmarkA-8   	 1000000	      1200 ns/op	     128 B/op	       2 allocs/op
+BenchmarkA-8   	 1000000	      1100 ns/op	     128 B/op	       2 allocs/op
+PASS
+ok  	example.com/a	2.345s
+pkg: example.com/b
+BenchmarkB/sub-8	     100	  20000000 ns/op	  52.43 MB/s
+`,
+			want: benchSamples{
+				"example.com/a.BenchmarkA-8": {
+					"ns/op":     {1200, 1100},
+					"B/op":      {128, 128},
+					"allocs/op": {2, 2},
+				},
+				"example.com/b.BenchmarkB/sub-8": {
+					"ns/op": {20000000},
+					"MB/s":  {52.43},
+				},
+			},
+		},
+		{
+			name: "no package",
+			content: `BenchmarkC 	 10	 100 ns/op
+`,
+			want: benchSamples{
+				"BenchmarkC": {
+					"ns/op": {100},
+				},
+			},
+		},
+		{
+			name: "ignored lines",
+			content: `Benchmark results
+BenchmarkD-8 	 not-a-number	 100 ns/op
+BenchmarkE-8 	 10	 100
+--- FAIL: BenchmarkF-8
+`,
+			want: benchSamples{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			samples := benchSamples{}
+			parseBenchmarks([]byte(tt.content), samples)
+			if !reflect.DeepEqual(samples, tt.want) {
+				t.Errorf("got %v, want %v", samples, tt.want)
+			}
+		})
+	}
+}
+
+func TestMedian(t *testing.T) {
+	tests := []struct {
+		values []float64
+		want   float64
+	}{
+		{values: []float64{3}, want: 3},
+		{values: []float64{3, 1, 2}, want: 2},
+		{values: []float64{4, 1, 3, 2}, want: 2.5},
+	}
+
+	for _, tt := range tests {
+		if got := median(tt.values); got != tt.want {
+			t.Errorf("median(%v) = %f, want %f", tt.values, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package golang
+
+import (
+	"bytes"
+	"context"
+	"os/exec"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/outofforest/libexec"
+)
+
+const buildInfoPackage = "github.com/outofforest/tools/pkg/buildinfo"
+
+// gitInfo contains information about the state of git repository.
+type gitInfo struct {
+	// Version is the version derived from git tags.
+	Version string
+
+	// Commit is the hash of HEAD commit.
+	Commit string
+
+	// CommitTime is the time of HEAD commit.
+	CommitTime time.Time
+
+	// Dirty is true if repository contains uncommitted changes.
+	Dirty bool
+}
+
+// getGitInfo returns information about the git repository containing the directory.
+func getGitInfo(ctx context.Context, dir string) (gitInfo, error) {
+	version, err := gitOutput(ctx, dir, "describe", "--tags", "--always")
+	if err != nil {
+		return gitInfo{}, err
+	}
+	commit, err := gitOutput(ctx, dir, "rev-parse", "HEAD")
+	if err != nil {
+		return gitInfo{}, err
+	}
+	commitTime, err := gitOutput(ctx, dir, "show", "-s", "--format=%cI", "HEAD")
+	if err != nil {
+		return gitInfo{}, err
+	}
+	status, err := gitOutput(ctx, dir, "status", "--porcelain")
+	if err != nil {
+		return gitInfo{}, err
+	}
+
+	t, err := time.Parse(time.RFC3339, commitTime)
+	if err != nil {
+		return gitInfo{}, errors.Wrapf(err, "parsing commit time '%s' failed", commitTime)
+	}
+
+	return gitInfo{
+		Version:    version,
+		Commit:     commit,
+		CommitTime: t.UTC(),
+		Dirty:      status != "",
+	}, nil
+}
+
+// embedBuildInfo adds linker variables defined by the buildinfo package to the config.
+// Commit time is used as the build time to keep builds reproducible.
+func embedBuildInfo(ctx context.Context, config BuildConfig) (BuildConfig, error) {
+	info, err := getGitInfo(ctx, config.PackagePath)
+	if err != nil {
+		return BuildConfig{}, err
+	}
+
+	dirty := "false"
+	if info.Dirty {
+		dirty = "true"
+	}
+
+	ldVariables := map[string]string{
+		buildInfoPackage + ".version":   info.Version,
+		buildInfoPackage + ".gitCommit": info.Commit,
+		buildInfoPackage + ".buildTime": info.CommitTime.Format(time.RFC3339),
+		buildInfoPackage + ".dirty":     dirty,
+	}
+	for k, v := range config.LDVariables {
+		ldVariables[k] = v
+	}
+	config.LDVariables = ldVariables
+
+	return config, nil
+}
+
+// ldVariableFlags returns -X linker flags setting the variables. Flags are passed to the linker in one
+// space-separated string, so each of them is quoted, and value containing both kinds of quotes can't be passed.
+func ldVariableFlags(variables map[string]string) ([]string, error) {
+	names := make([]string, 0, len(variables))
+	for name := range variables {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	flags := make([]string, 0, len(names))
+	for _, name := range names {
+		quote := "'"
+		if strings.Contains(variables[name], quote) {
+			quote = `"`
+			if strings.Contains(variables[name], quote) {
+				return nil, errors.Errorf("value of linker variable '%s' contains both single and double quotes",
+					name)
+			}
+		}
+		flags = append(flags, "-X", quote+name+"="+variables[name]+quote)
+	}
+	return flags, nil
+}
+
+func gitOutput(ctx context.Context, dir string, args ...string) (string, error) {
+	buf := &bytes.Buffer{}
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Stdout = buf
+	if err := libexec.Exec(ctx, cmd); err != nil {
+		return "", errors.Wrapf(err, "git command failed in '%s'", dir)
+	}
+	return strings.TrimSpace(buf.String()), nil
+}
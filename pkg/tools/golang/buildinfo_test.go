@@ -0,0 +1,55 @@
+package golang
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLDVariableFlags(t *testing.T) {
+	tests := []struct {
+		name      string
+		variables map[string]string
+		flags     []string
+		wantErr   bool
+	}{
+		{name: "no variables", variables: map[string]string{}, flags: []string{}},
+		{
+			name:      "sorted variables",
+			variables: map[string]string{"b.Version": "v1.0.0", "a.Name": "app name"},
+			flags:     []string{"-X", "'a.Name=app name'", "-X", "'b.Version=v1.0.0'"},
+		},
+		{
+			name:      "single quote",
+			variables: map[string]string{"a.Name": "app's name"},
+			flags:     []string{"-X", `"a.Name=app's name"`},
+		},
+		{
+			name:      "double quote",
+			variables: map[string]string{"a.Name": `"app"`},
+			flags:     []string{"-X", `'a.Name="app"'`},
+		},
+		{
+			name:      "both quotes",
+			variables: map[string]string{"a.Name": `"app's"`},
+			wantErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			flags, err := ldVariableFlags(tt.variables)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("error expected")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(flags, tt.flags) {
+				t.Errorf("got %q, want %q", flags, tt.flags)
+			}
+		})
+	}
+}
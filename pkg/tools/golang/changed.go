@@ -0,0 +1,237 @@
+package golang
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/samber/lo"
+	"go.uber.org/zap"
+
+	"github.com/outofforest/build/v2/pkg/helpers"
+	"github.com/outofforest/build/v2/pkg/tools"
+	"github.com/outofforest/build/v2/pkg/types"
+	"github.com/outofforest/libexec"
+	"github.com/outofforest/logger"
+)
+
+const (
+	// BaseRefEnv is the name of environment variable containing git reference used to detect changed files.
+	BaseRefEnv = "GIT_BASE_REF"
+
+	defaultBaseRef = "origin/main"
+)
+
+type goPackage struct {
+	ImportPath   string
+	Dir          string
+	Imports      []string
+	TestImports  []string
+	XTestImports []string
+}
+
+// UnitTestsChanged runs go unit tests of packages affected by changes made since git reference
+// taken from GIT_BASE_REF environment variable.
+func UnitTestsChanged(ctx context.Context, deps types.DepsFunc) error {
+	return unitTestsChanged(ctx, deps, DefaultUnitTestsConfig())
+}
+
+// UnitTestsChangedWithConfig returns command running go unit tests of affected packages using provided config.
+func UnitTestsChangedWithConfig(config UnitTestsConfig) types.CommandFunc {
+	return func(ctx context.Context, deps types.DepsFunc) error {
+		return unitTestsChanged(ctx, deps, config)
+	}
+}
+
+func unitTestsChanged(ctx context.Context, deps types.DepsFunc, config UnitTestsConfig) error {
+	deps(EnsureGo)
+
+	affected, err := affectedPackages(ctx, baseRef(), config.Tags)
+	if err != nil {
+		return err
+	}
+
+	// Coverage thresholds can't be verified because only subset of tests is executed.
+	config.Coverage = CoverageConfig{}
+	return unitTests(ctx, deps, config, affected)
+}
+
+// LintChanged lints go code reporting only issues introduced since the merge base of git reference taken from
+// GIT_BASE_REF environment variable and HEAD. Modules without changed go files are skipped.
+func LintChanged(ctx context.Context, deps types.DepsFunc) error {
+	return lint(ctx, deps, LintConfig{BaseRef: baseRef()})
+}
+
+func baseRef() string {
+	if ref := os.Getenv(BaseRefEnv); ref != "" {
+		return ref
+	}
+	return defaultBaseRef
+}
+
+// affectedPackages returns packages affected by changed files, keyed by the module path.
+// Packages depending on changed ones, including those from other modules, are affected too.
+func affectedPackages(ctx context.Context, baseRef string, tags []string) (map[string][]string, error) {
+	log := logger.Get(ctx)
+
+	changed, err := changedFiles(ctx, baseRef)
+	if err != nil {
+		return nil, err
+	}
+
+	modules := map[string][]goPackage{}
+	reverseDeps := map[string][]string{}
+	affected := map[string]bool{}
+	err = helpers.OnModule("go.mod", func(path string) error {
+		path = lo.Must(filepath.Abs(lo.Must(filepath.EvalSymlinks(path))))
+
+		pkgs, err := listPackages(ctx, path, tags)
+		if err != nil {
+			return err
+		}
+		modules[path] = pkgs
+
+		for _, pkg := range pkgs {
+			for _, imp := range lo.Uniq(append(append(append([]string{}, pkg.Imports...), pkg.TestImports...),
+				pkg.XTestImports...)) {
+				reverseDeps[imp] = append(reverseDeps[imp], pkg.ImportPath)
+			}
+		}
+
+		for _, file := range changed {
+			for _, pkg := range changedPackages(path, pkgs, file) {
+				affected[pkg] = true
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	queue := lo.Keys(affected)
+	for len(queue) > 0 {
+		pkg := queue[0]
+		queue = queue[1:]
+		for _, dependant := range reverseDeps[pkg] {
+			if !affected[dependant] {
+				affected[dependant] = true
+				queue = append(queue, dependant)
+			}
+		}
+	}
+
+	result := map[string][]string{}
+	for path, pkgs := range modules {
+		result[path] = []string{}
+		for _, pkg := range pkgs {
+			if affected[pkg.ImportPath] {
+				result[path] = append(result[path], pkg.ImportPath)
+			}
+		}
+		log.Info("Affected packages found", zap.String("path", path), zap.Strings("packages", result[path]))
+	}
+	return result, nil
+}
+
+// changedPackages returns packages of the module affected by the changed file.
+func changedPackages(modulePath string, pkgs []goPackage, file string) []string {
+	if !belongsToModule(modulePath, file) {
+		return nil
+	}
+
+	dir := filepath.Dir(file)
+	if dir == modulePath && (filepath.Base(file) == "go.mod" || filepath.Base(file) == "go.sum") {
+		return lo.Map(pkgs, func(pkg goPackage, _ int) string {
+			return pkg.ImportPath
+		})
+	}
+
+	// File might be located in subdirectory of the package, e.g. testdata or embedded files.
+	for {
+		for _, pkg := range pkgs {
+			if pkg.Dir == dir {
+				return []string{pkg.ImportPath}
+			}
+		}
+		if dir == modulePath {
+			return nil
+		}
+		dir = filepath.Dir(dir)
+	}
+}
+
+// belongsToModule checks if file is located inside the module and not inside any module nested in it.
+func belongsToModule(modulePath, file string) bool {
+	if !strings.HasPrefix(file, modulePath+string(filepath.Separator)) {
+		return false
+	}
+	for dir := filepath.Dir(file); dir != modulePath; dir = filepath.Dir(dir) {
+		if _, err := os.Stat(filepath.Join(dir, "go.mod")); err == nil {
+			return false
+		}
+	}
+	return true
+}
+
+// changedFiles returns absolute paths of files changed since the merge base of the reference and HEAD,
+// including uncommitted and untracked ones.
+func changedFiles(ctx context.Context, baseRef string) ([]string, error) {
+	repoDir, err := gitOutput(ctx, ".", "rev-parse", "--show-toplevel")
+	if err != nil {
+		return nil, err
+	}
+	repoDir = lo.Must(filepath.EvalSymlinks(repoDir))
+
+	mergeBase, err := gitOutput(ctx, repoDir, "merge-base", baseRef, "HEAD")
+	if err != nil {
+		return nil, err
+	}
+	diff, err := gitOutput(ctx, repoDir, "diff", "--name-only", "--no-renames", mergeBase)
+	if err != nil {
+		return nil, err
+	}
+	untracked, err := gitOutput(ctx, repoDir, "ls-files", "--others", "--exclude-standard")
+	if err != nil {
+		return nil, err
+	}
+
+	files := []string{}
+	for _, file := range strings.Split(diff+"\n"+untracked, "\n") {
+		if file = strings.TrimSpace(file); file != "" {
+			files = append(files, filepath.Join(repoDir, file))
+		}
+	}
+	return lo.Uniq(files), nil
+}
+
+func listPackages(ctx context.Context, modulePath string, tags []string) ([]goPackage, error) {
+	buf := &bytes.Buffer{}
+	cmd := exec.Command(tools.Bin(ctx, "bin/go", tools.PlatformLocal), "list", "-e", "-json",
+		"-tags="+strings.Join(tags, ","), "./...")
+	cmd.Env = env(ctx)
+	cmd.Dir = modulePath
+	cmd.Stdout = buf
+	if err := libexec.Exec(ctx, cmd); err != nil {
+		return nil, errors.Wrapf(err, "listing packages failed in module '%s'", modulePath)
+	}
+
+	pkgs := []goPackage{}
+	decoder := json.NewDecoder(buf)
+	for {
+		var pkg goPackage
+		if err := decoder.Decode(&pkg); err != nil {
+			if errors.Is(err, io.EOF) {
+				return pkgs, nil
+			}
+			return nil, errors.Wrapf(err, "decoding package list failed in module '%s'", modulePath)
+		}
+		pkgs = append(pkgs, pkg)
+	}
+}
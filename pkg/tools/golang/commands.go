@@ -1,9 +1,53 @@
 package golang
 
-import "github.com/outofforest/build/v2/pkg/types"
+import (
+	"github.com/outofforest/build/v2/pkg/types"
+	"github.com/outofforest/tools/pkg/tools/docker"
+	"github.com/outofforest/tools/pkg/tools/sarif"
+)
 
 // Commands is a set of commands useful for any go environment.
 var Commands = map[string]types.Command{
+	"bench/go": {
+		Description: "Runs go benchmarks and compares results with the baseline",
+		Fn:          Bench,
+	},
+	"bench/go/baseline": {
+		Description: "Runs go benchmarks and stores results as the baseline",
+		Fn:          BenchBaseline,
+	},
+	"build/go/verify": {
+		Description: "Verifies that builds of all the main go packages are reproducible",
+		Fn:          VerifyBuilds,
+	},
+	"deps/go/align": {
+		Description: "Aligns go dependencies and go directive of all the modules to the highest versions",
+		Fn:          AlignDeps,
+	},
+	"deps/go/check": {
+		Description: "Reports go dependencies required at different versions by modules",
+		Fn:          CheckDeps,
+	},
+	"docker/go/cache/prune": {
+		Description: "Removes go module and build caches used by docker builds",
+		Fn:          PruneDockerCache,
+	},
+	"docker/prune": {
+		Description: "Removes exited build containers, stale builder images and go builder images of outdated configs",
+		Fn:          PruneDocker,
+	},
+	"docker/prune/dry": {
+		Description: "Lists docker resources which would be removed by docker/prune",
+		Fn:          PruneDockerWithConfig(docker.PruneConfig{DryRun: true}, BuilderImageConfig{}),
+	},
+	"fix/go": {
+		Description: "Fixes go code issues which might be fixed automatically",
+		Fn:          Fix,
+	},
+	"fuzz/go": {
+		Description: "Runs go fuzz tests",
+		Fn:          Fuzz,
+	},
 	"generate/go": {
 		Description: "Runs generators in go code",
 		Fn:          Generate,
@@ -12,12 +56,67 @@ var Commands = map[string]types.Command{
 		Description: "Lints go code",
 		Fn:          Lint,
 	},
+	"lint/go/baseline": {
+		Description: "Records current findings of go linter in baseline file of each module",
+		Fn:          LintBaseline,
+	},
+	"lint/go/baseline/prune": {
+		Description: "Removes findings which are no longer reported from baseline files of go linter",
+		Fn:          PruneLintBaseline,
+	},
+	"lint/go/changed": {
+		Description: "Lints go code reporting only issues introduced since GIT_BASE_REF",
+		Fn:          LintChanged,
+	},
+	"lint/go/config": {
+		Description: "Prints effective configuration of go linter for each module",
+		Fn:          PrintLintConfig,
+	},
+	"lint/go/sarif": {
+		Description: "Lints go code and stores findings in " + sarif.DefaultPath,
+		Fn:          LintWithConfig(LintConfig{SARIFPath: sarif.DefaultPath}),
+	},
+	"lint/go/work": {
+		Description: "Lints go code in workspace mode",
+		Fn:          LintWithConfig(LintConfig{Workspace: true}),
+	},
 	"test/go": {
 		Description: "Runs go unit tests",
 		Fn:          UnitTests,
 	},
+	"test/go/changed": {
+		Description: "Runs go unit tests of packages affected by changes made since GIT_BASE_REF",
+		Fn:          UnitTestsChanged,
+	},
+	"test/go/short": {
+		Description: "Runs go unit tests in short mode without race detector",
+		Fn: UnitTestsWithConfig(UnitTestsConfig{
+			Tags:  []string{"testing"},
+			Short: true,
+		}),
+	},
+	"test/go/work": {
+		Description: "Runs go unit tests in workspace mode",
+		Fn: UnitTestsWithConfig(func() UnitTestsConfig {
+			config := DefaultUnitTestsConfig()
+			config.Workspace = true
+			return config
+		}()),
+	},
 	"tidy/go": {
 		Description: "Tidies up the go code",
 		Fn:          Tidy,
 	},
+	"tidy/go/work": {
+		Description: "Synchronizes go workspace dependencies with modules and tidies up the go code",
+		Fn:          TidyWithConfig(TidyConfig{Workspace: true}),
+	},
+	"vuln/go": {
+		Description: "Scans go dependencies for known vulnerabilities",
+		Fn:          Vuln,
+	},
+	"work/go": {
+		Description: "Generates go.work file covering all the go modules",
+		Fn:          GenerateWorkspace,
+	},
 }
@@ -0,0 +1,306 @@
+package golang
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	"github.com/outofforest/build/v2/pkg/tools"
+	"github.com/outofforest/libexec"
+	"github.com/outofforest/logger"
+)
+
+const (
+	coverageReportSubDir  = "report"
+	coverageMergedProfile = "merged.out"
+	coverageMergedReport  = "merged.html"
+	coverageSummaryFile   = "summary.txt"
+)
+
+// CoverageConfig is the configuration of coverage checks.
+type CoverageConfig struct {
+	// MinTotal is the minimum coverage, in percents, required for all the modules together.
+	MinTotal float64
+
+	// MinModule is the minimum coverage, in percents, required for each module.
+	MinModule float64
+
+	// MinModules overrides MinModule for particular modules, keyed by module path relative to the repository root.
+	MinModules map[string]float64
+}
+
+type moduleCoverage struct {
+	Module     string
+	ModulePath string
+	Path       string
+}
+
+type coverageBlock struct {
+	Statements int
+	Count      int
+}
+
+type coverageStats struct {
+	Statements int
+	Covered    int
+}
+
+func (cs *coverageStats) add(block coverageBlock) {
+	cs.Statements += block.Statements
+	if block.Count > 0 {
+		cs.Covered += block.Statements
+	}
+}
+
+func (cs coverageStats) percent() float64 {
+	if cs.Statements == 0 {
+		return 100
+	}
+	return 100 * float64(cs.Covered) / float64(cs.Statements)
+}
+
+// reportCoverage merges coverage profiles, produces HTML and summary reports and verifies coverage thresholds.
+func reportCoverage(ctx context.Context, covDir string, profiles []moduleCoverage, config CoverageConfig) error {
+	reportDir := filepath.Join(covDir, coverageReportSubDir)
+	if err := os.MkdirAll(reportDir, 0o700); err != nil {
+		return errors.WithStack(err)
+	}
+
+	mode := "set"
+	merged := map[string]coverageBlock{}
+	moduleStats := map[string]coverageStats{}
+	packageStats := map[string]map[string]coverageStats{}
+
+	for _, profile := range profiles {
+		profileMode, blocks, err := parseCoverageProfile(profile.Path)
+		if err != nil {
+			return err
+		}
+		if profileMode != "" {
+			mode = profileMode
+		}
+
+		stats := coverageStats{}
+		pkgStats := map[string]coverageStats{}
+		for key, block := range blocks {
+			stats.add(block)
+			pkg := path.Dir(key[:strings.LastIndex(key, ":")])
+			s := pkgStats[pkg]
+			s.add(block)
+			pkgStats[pkg] = s
+
+			mergedBlock := merged[key]
+			mergedBlock.Statements = block.Statements
+			mergedBlock.Count = mergeCoverageCounts(mode, mergedBlock.Count, block.Count)
+			merged[key] = mergedBlock
+		}
+		moduleStats[profile.Module] = stats
+		packageStats[profile.Module] = pkgStats
+
+		htmlReport := filepath.Join(reportDir, filepath.Base(profile.Path)+".html")
+		cmd := exec.Command(tools.Bin(ctx, "bin/go", tools.PlatformLocal), "tool", "cover",
+			"-html", profile.Path, "-o", htmlReport)
+		cmd.Env = env(ctx)
+		cmd.Dir = profile.ModulePath
+		if err := libexec.Exec(ctx, cmd); err != nil {
+			return errors.Wrapf(err, "generating HTML coverage report failed in module '%s'", profile.ModulePath)
+		}
+	}
+
+	mergedProfile := filepath.Join(reportDir, coverageMergedProfile)
+	if err := storeCoverageProfile(mergedProfile, mode, merged); err != nil {
+		return err
+	}
+	if len(profiles) > 0 {
+		modulePaths := make([]string, 0, len(profiles))
+		for _, profile := range profiles {
+			modulePaths = append(modulePaths, profile.ModulePath)
+		}
+		if err := storeMergedCoverageHTML(ctx, mergedProfile, filepath.Join(reportDir, coverageMergedReport),
+			modulePaths); err != nil {
+			return err
+		}
+	}
+
+	total := coverageStats{}
+	for _, block := range merged {
+		total.add(block)
+	}
+
+	summaryPath := filepath.Join(reportDir, coverageSummaryFile)
+	summaryFile, err := os.OpenFile(summaryPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o600)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer summaryFile.Close()
+
+	if err := printCoverageSummary(summaryFile, moduleStats, packageStats, total); err != nil {
+		return err
+	}
+
+	logger.Get(ctx).Info("Coverage report generated",
+		zap.String("path", reportDir),
+		zap.String("summary", summaryPath),
+		zap.String("total", fmt.Sprintf("%.1f%%", total.percent())))
+
+	return checkCoverage(moduleStats, total, config)
+}
+
+// storeMergedCoverageHTML generates HTML report of the merged profile. Profile covers many modules,
+// so the report is generated in temporary workspace using all of them.
+func storeMergedCoverageHTML(ctx context.Context, profilePath, reportPath string, modulePaths []string) error {
+	workDir, err := os.MkdirTemp("", "coverage-*")
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer os.RemoveAll(workDir)
+
+	envs := withEnv(env(ctx), "GOWORK="+filepath.Join(workDir, WorkspaceFile))
+
+	initCmd := exec.Command(tools.Bin(ctx, "bin/go", tools.PlatformLocal),
+		append([]string{"work", "init"}, modulePaths...)...)
+	initCmd.Env = envs
+	initCmd.Dir = workDir
+
+	coverCmd := exec.Command(tools.Bin(ctx, "bin/go", tools.PlatformLocal), "tool", "cover",
+		"-html", profilePath, "-o", reportPath)
+	coverCmd.Env = envs
+	coverCmd.Dir = workDir
+
+	if err := libexec.Exec(ctx, initCmd, coverCmd); err != nil {
+		return errors.Wrap(err, "generating merged HTML coverage report failed")
+	}
+	return nil
+}
+
+func checkCoverage(moduleStats map[string]coverageStats, total coverageStats, config CoverageConfig) error {
+	failures := []string{}
+	if total.percent() < config.MinTotal {
+		failures = append(failures, fmt.Sprintf("total coverage %.1f%% is below %.1f%%", total.percent(),
+			config.MinTotal))
+	}
+	for _, module := range sortedKeys(moduleStats) {
+		minCoverage := config.MinModule
+		if m, exists := config.MinModules[module]; exists {
+			minCoverage = m
+		}
+		if coverage := moduleStats[module].percent(); coverage < minCoverage {
+			failures = append(failures, fmt.Sprintf("coverage %.1f%% of module '%s' is below %.1f%%", coverage,
+				module, minCoverage))
+		}
+	}
+	if len(failures) > 0 {
+		return errors.Errorf("coverage requirements not met:\n%s", strings.Join(failures, "\n"))
+	}
+	return nil
+}
+
+func printCoverageSummary(
+	w io.Writer,
+	moduleStats map[string]coverageStats,
+	packageStats map[string]map[string]coverageStats,
+	total coverageStats,
+) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "MODULE\tPACKAGE\tSTATEMENTS\tCOVERAGE")
+	for _, module := range sortedKeys(moduleStats) {
+		for _, pkg := range sortedKeys(packageStats[module]) {
+			stats := packageStats[module][pkg]
+			fmt.Fprintf(tw, "%s\t%s\t%d\t%.1f%%\n", module, pkg, stats.Statements, stats.percent())
+		}
+		stats := moduleStats[module]
+		fmt.Fprintf(tw, "%s\t(module)\t%d\t%.1f%%\n", module, stats.Statements, stats.percent())
+	}
+	fmt.Fprintf(tw, "(total)\t\t%d\t%.1f%%\n", total.Statements, total.percent())
+	return errors.WithStack(tw.Flush())
+}
+
+// parseCoverageProfile parses coverage profile. Returned blocks are keyed by file and position,
+// blocks reported many times are merged.
+func parseCoverageProfile(profilePath string) (string, map[string]coverageBlock, error) {
+	f, err := os.Open(profilePath)
+	if err != nil {
+		return "", nil, errors.WithStack(err)
+	}
+	defer f.Close()
+
+	var mode string
+	blocks := map[string]coverageBlock{}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		if m, ok := strings.CutPrefix(line, "mode:"); ok {
+			mode = strings.TrimSpace(m)
+			continue
+		}
+
+		// Line format: file.go:startLine.startCol,endLine.endCol numStatements count
+		fields := strings.Fields(line)
+		if len(fields) != 3 {
+			return "", nil, errors.Errorf("invalid line '%s' in coverage profile '%s'", line, profilePath)
+		}
+		statements, err := strconv.Atoi(fields[1])
+		if err != nil {
+			return "", nil, errors.Wrapf(err, "invalid line '%s' in coverage profile '%s'", line, profilePath)
+		}
+		count, err := strconv.Atoi(fields[2])
+		if err != nil {
+			return "", nil, errors.Wrapf(err, "invalid line '%s' in coverage profile '%s'", line, profilePath)
+		}
+
+		block := blocks[fields[0]]
+		block.Statements = statements
+		block.Count = mergeCoverageCounts(mode, block.Count, count)
+		blocks[fields[0]] = block
+	}
+	if err := scanner.Err(); err != nil {
+		return "", nil, errors.WithStack(err)
+	}
+	return mode, blocks, nil
+}
+
+func storeCoverageProfile(profilePath, mode string, blocks map[string]coverageBlock) error {
+	f, err := os.OpenFile(profilePath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o600)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer f.Close()
+
+	w := bufio.NewWriter(f)
+	fmt.Fprintf(w, "mode: %s\n", mode)
+	for _, key := range sortedKeys(blocks) {
+		fmt.Fprintf(w, "%s %d %d\n", key, blocks[key].Statements, blocks[key].Count)
+	}
+	return errors.WithStack(w.Flush())
+}
+
+func mergeCoverageCounts(mode string, count1, count2 int) int {
+	if mode == "set" {
+		return max(count1, count2)
+	}
+	return count1 + count2
+}
+
+func sortedKeys[T any](m map[string]T) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,179 @@
+package golang
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParseCoverageProfile(t *testing.T) {
+	tests := []struct {
+		name    string
+		profile string
+		mode    string
+		blocks  map[string]coverageBlock
+		wantErr bool
+	}{
+		{
+			name:    "empty",
+			profile: "mode: set\n",
+			mode:    "set",
+			blocks:  map[string]coverageBlock{},
+		},
+		{
+			name: "set",
+			profile: "mode: set\n" +
+				"example.com/a/a.go:3.20,5.2 1 1\n" +
+				"example.com/a/a.go:5.2,7.3 2 0\n",
+			mode: "set",
+			blocks: map[string]coverageBlock{
+				"example.com/a/a.go:3.20,5.2": {Statements: 1, Count: 1},
+				"example.com/a/a.go:5.2,7.3":  {Statements: 2, Count: 0},
+			},
+		},
+		{
+			name: "duplicated blocks in set mode",
+			profile: "mode: set\n" +
+				"example.com/a/a.go:3.20,5.2 1 0\n" +
+				"example.com/a/a.go:3.20,5.2 1 1\n" +
+				"example.com/a/a.go:3.20,5.2 1 0\n",
+			mode: "set",
+			blocks: map[string]coverageBlock{
+				"example.com/a/a.go:3.20,5.2": {Statements: 1, Count: 1},
+			},
+		},
+		{
+			name: "duplicated blocks in count mode",
+			profile: "mode: count\n" +
+				"example.com/a/a.go:3.20,5.2 1 2\n" +
+				"\n" +
+				"example.com/a/a.go:3.20,5.2 1 3\n",
+			mode: "count",
+			blocks: map[string]coverageBlock{
+				"example.com/a/a.go:3.20,5.2": {Statements: 1, Count: 5},
+			},
+		},
+		{
+			name:    "missing field",
+			profile: "mode: set\nexample.com/a/a.go:3.20,5.2 1\n",
+			wantErr: true,
+		},
+		{
+			name:    "invalid count",
+			profile: "mode: set\nexample.com/a/a.go:3.20,5.2 1 x\n",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			profilePath := filepath.Join(t.TempDir(), "profile.out")
+			if err := os.WriteFile(profilePath, []byte(tt.profile), 0o600); err != nil {
+				t.Fatal(err)
+			}
+
+			mode, blocks, err := parseCoverageProfile(profilePath)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("error expected")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if mode != tt.mode {
+				t.Errorf("mode: got %q, want %q", mode, tt.mode)
+			}
+			if !reflect.DeepEqual(blocks, tt.blocks) {
+				t.Errorf("blocks: got %v, want %v", blocks, tt.blocks)
+			}
+		})
+	}
+}
+
+func TestStoreCoverageProfile(t *testing.T) {
+	blocks := map[string]coverageBlock{
+		"example.com/b/b.go:1.1,2.2": {Statements: 3, Count: 0},
+		"example.com/a/a.go:1.1,2.2": {Statements: 1, Count: 4},
+	}
+
+	profilePath := filepath.Join(t.TempDir(), "merged.out")
+	if err := storeCoverageProfile(profilePath, "count", blocks); err != nil {
+		t.Fatal(err)
+	}
+
+	content, err := os.ReadFile(profilePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	const want = "mode: count\n" +
+		"example.com/a/a.go:1.1,2.2 1 4\n" +
+		"example.com/b/b.go:1.1,2.2 3 0\n"
+	if string(content) != want {
+		t.Errorf("got:\n%s\nwant:\n%s", content, want)
+	}
+
+	mode, parsed, err := parseCoverageProfile(profilePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mode != "count" || !reflect.DeepEqual(parsed, blocks) {
+		t.Errorf("stored profile parsed as %q, %v", mode, parsed)
+	}
+}
+
+func TestMergeCoverageCounts(t *testing.T) {
+	tests := []struct {
+		mode   string
+		count1 int
+		count2 int
+		want   int
+	}{
+		{mode: "set", count1: 0, count2: 0, want: 0},
+		{mode: "set", count1: 1, count2: 0, want: 1},
+		{mode: "set", count1: 0, count2: 1, want: 1},
+		{mode: "set", count1: 1, count2: 1, want: 1},
+		{mode: "count", count1: 2, count2: 3, want: 5},
+		{mode: "atomic", count1: 0, count2: 7, want: 7},
+	}
+
+	for _, tt := range tests {
+		if got := mergeCoverageCounts(tt.mode, tt.count1, tt.count2); got != tt.want {
+			t.Errorf("mergeCoverageCounts(%q, %d, %d) = %d, want %d", tt.mode, tt.count1, tt.count2, got, tt.want)
+		}
+	}
+}
+
+func TestCheckCoverage(t *testing.T) {
+	moduleStats := map[string]coverageStats{
+		"a": {Statements: 10, Covered: 9},
+		"b": {Statements: 10, Covered: 5},
+	}
+	total := coverageStats{Statements: 20, Covered: 14}
+
+	tests := []struct {
+		name    string
+		config  CoverageConfig
+		wantErr bool
+	}{
+		{name: "no thresholds", config: CoverageConfig{}},
+		{name: "total met", config: CoverageConfig{MinTotal: 70}},
+		{name: "total not met", config: CoverageConfig{MinTotal: 71}, wantErr: true},
+		{name: "module not met", config: CoverageConfig{MinModule: 60}, wantErr: true},
+		{
+			name:   "module overridden",
+			config: CoverageConfig{MinModule: 60, MinModules: map[string]float64{"b": 50}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkCoverage(moduleStats, total, tt.config)
+			if tt.wantErr != (err != nil) {
+				t.Errorf("unexpected result: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,88 @@
+package golang
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"os/exec"
+	"sort"
+
+	"github.com/pkg/errors"
+
+	"github.com/outofforest/build/v2/pkg/tools"
+	"github.com/outofforest/libexec"
+)
+
+// Dependency is the module providing packages compiled into the go module.
+type Dependency struct {
+	// Path is the path of the module.
+	Path string
+
+	// Version is the version of the module. It is empty if module is replaced by local directory.
+	Version string
+
+	// Dir is the directory containing source code of the module.
+	Dir string
+}
+
+// Dependencies returns modules providing packages compiled into the module. The module itself,
+// standard library and dependencies of tests are not included.
+func Dependencies(ctx context.Context, modulePath string) ([]Dependency, error) {
+	buf := &bytes.Buffer{}
+	cmd := exec.Command(tools.Bin(ctx, "bin/go", tools.PlatformLocal), "list", "-deps", "-json", "./...")
+	cmd.Env = env(ctx)
+	cmd.Dir = modulePath
+	cmd.Stdout = buf
+	if err := libexec.Exec(ctx, cmd); err != nil {
+		return nil, errors.Wrapf(err, "listing dependencies failed in module '%s'", modulePath)
+	}
+
+	type module struct {
+		Path    string
+		Version string
+		Dir     string
+		Main    bool
+		Replace *module
+	}
+
+	deps := map[string]Dependency{}
+	decoder := json.NewDecoder(buf)
+	for {
+		var pkg struct {
+			Standard bool
+			Module   *module
+		}
+		if err := decoder.Decode(&pkg); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, errors.WithStack(err)
+		}
+		if pkg.Standard || pkg.Module == nil || pkg.Module.Main {
+			continue
+		}
+
+		dep := Dependency{
+			Path:    pkg.Module.Path,
+			Version: pkg.Module.Version,
+			Dir:     pkg.Module.Dir,
+		}
+		if pkg.Module.Replace != nil {
+			dep.Version = pkg.Module.Replace.Version
+			if pkg.Module.Replace.Dir != "" {
+				dep.Dir = pkg.Module.Replace.Dir
+			}
+		}
+		deps[dep.Path] = dep
+	}
+
+	result := make([]Dependency, 0, len(deps))
+	for _, dep := range deps {
+		result = append(result, dep)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Path < result[j].Path
+	})
+	return result, nil
+}
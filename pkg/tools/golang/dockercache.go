@@ -0,0 +1,46 @@
+package golang
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	"github.com/outofforest/build/v2/pkg/tools"
+	"github.com/outofforest/build/v2/pkg/types"
+	"github.com/outofforest/logger"
+)
+
+// dockerCacheDir returns the directory where go module and build caches used by docker builds for the platform
+// are persisted. Caches are separated by architecture because builds for different ones never share objects.
+func dockerCacheDir(ctx context.Context, platform tools.Platform) string {
+	return filepath.Join(dockerCacheRootDir(ctx), platform.Arch)
+}
+
+func dockerCacheRootDir(ctx context.Context) string {
+	return filepath.Join(tools.DevDir(ctx), "go", "docker-cache")
+}
+
+// PruneDockerCache removes go module and build caches used by docker builds.
+func PruneDockerCache(ctx context.Context, _ types.DepsFunc) error {
+	cacheDir := dockerCacheRootDir(ctx)
+	logger.Get(ctx).Info("Removing caches of docker builds", zap.String("path", cacheDir))
+
+	// Module cache might contain read-only directories, so permissions must be fixed before removing them.
+	err := filepath.WalkDir(cacheDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return errors.WithStack(os.Chmod(path, 0o700))
+		}
+		return nil
+	})
+	if err != nil && !os.IsNotExist(err) {
+		return errors.WithStack(err)
+	}
+	return errors.WithStack(os.RemoveAll(cacheDir))
+}
@@ -0,0 +1,44 @@
+package golang
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+
+	"github.com/outofforest/build/v2/pkg/types"
+	"github.com/outofforest/tools/pkg/tools/docker"
+)
+
+// PruneDocker removes stopped build containers, builder images of unused tool versions and go builder images
+// not rendered from the default config. Repositories using custom builder images must register command
+// returned by PruneDockerWithConfig instead, otherwise their builder images are removed.
+func PruneDocker(ctx context.Context, deps types.DepsFunc) error {
+	return pruneDocker(ctx, deps, docker.PruneConfig{}, []BuilderImageConfig{{}})
+}
+
+// PruneDockerWithConfig returns command removing stale docker resources using provided config.
+// builderConfigs must contain all the builder image configs used by the repository, including the default one
+// if it is used, because go builder images not rendered from any of them are removed.
+func PruneDockerWithConfig(config docker.PruneConfig, builderConfigs ...BuilderImageConfig) types.CommandFunc {
+	return func(ctx context.Context, deps types.DepsFunc) error {
+		return pruneDocker(ctx, deps, config, builderConfigs)
+	}
+}
+
+func pruneDocker(
+	ctx context.Context,
+	deps types.DepsFunc,
+	config docker.PruneConfig,
+	builderConfigs []BuilderImageConfig,
+) error {
+	if len(builderConfigs) == 0 {
+		return errors.New("builder image configs used by the repository must be provided")
+	}
+
+	images, err := DockerBuilderImages(builderConfigs...)
+	if err != nil {
+		return err
+	}
+	config.InUseImages = append(append([]string{}, config.InUseImages...), images...)
+	return docker.PruneWithConfig(config)(ctx, deps)
+}
@@ -0,0 +1,135 @@
+package golang
+
+import (
+	"context"
+	"io"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/samber/lo"
+	"go.uber.org/zap"
+
+	"github.com/outofforest/build/v2/pkg/tools"
+	"github.com/outofforest/build/v2/pkg/types"
+	"github.com/outofforest/libexec"
+	"github.com/outofforest/logger"
+)
+
+// FixConfig is the configuration for fixing go code.
+type FixConfig struct {
+	// Workers is the maximum number of modules fixed concurrently. If zero, number of CPUs is used.
+	Workers int
+}
+
+// Fix fixes issues reported by the linter which might be fixed automatically and formats the go code.
+func Fix(ctx context.Context, deps types.DepsFunc) error {
+	return fix(ctx, deps, FixConfig{})
+}
+
+// FixWithConfig returns command fixing go code using provided config.
+func FixWithConfig(config FixConfig) types.CommandFunc {
+	return func(ctx context.Context, deps types.DepsFunc) error {
+		return fix(ctx, deps, config)
+	}
+}
+
+func fix(ctx context.Context, deps types.DepsFunc, config FixConfig) error {
+	deps(EnsureGo, EnsureGolangCI, storeLintConfig)
+
+	log := logger.Get(ctx)
+	repoDir := lo.Must(filepath.Abs("."))
+
+	var mu sync.Mutex
+	var changed []string
+	err := onModules(ctx, "go.mod", config.Workers, func(ctx context.Context, path string, output io.Writer) error {
+		path = lo.Must(filepath.Abs(path))
+
+		files, err := moduleGoFiles(path)
+		if err != nil {
+			return err
+		}
+		if len(files) == 0 {
+			logger.Get(ctx).Info("No code to fix", zap.String("path", path))
+			return nil
+		}
+
+		checksumsBefore, err := checksums(files)
+		if err != nil {
+			return err
+		}
+
+		lintConfig, err := moduleLintConfigPath(ctx, repoDir, path)
+		if err != nil {
+			return err
+		}
+
+		logger.Get(ctx).Info("Fixing code", zap.String("path", path))
+		// Issues which can't be fixed automatically are reported by lint command, so they are ignored here.
+		lintCmd := exec.Command(tools.Bin(ctx, "bin/golangci-lint", tools.PlatformLocal), "run",
+			"--config", lintConfig,
+			"--allow-parallel-runners",
+			"--fix",
+			"--issues-exit-code", "0",
+		)
+		lintCmd.Env = env(ctx)
+		lintCmd.Dir = path
+		lintCmd.Stdout = output
+		lintCmd.Stderr = output
+
+		fmtCmd := exec.Command(tools.Bin(ctx, "bin/gofmt", tools.PlatformLocal), append([]string{"-s", "-w"},
+			files...)...)
+		fmtCmd.Env = env(ctx)
+		fmtCmd.Dir = path
+		fmtCmd.Stdout = output
+		fmtCmd.Stderr = output
+
+		if err := libexec.Exec(ctx, lintCmd, fmtCmd); err != nil {
+			return errors.Wrapf(err, "fixing code failed in module '%s'", path)
+		}
+
+		checksumsAfter, err := checksums(files)
+		if err != nil {
+			return err
+		}
+
+		moduleChanged := []string{}
+		for _, file := range files {
+			if checksumsBefore[file] != checksumsAfter[file] {
+				moduleChanged = append(moduleChanged, lo.Must(filepath.Rel(repoDir, file)))
+			}
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		changed = append(changed, moduleChanged...)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if len(changed) == 0 {
+		log.Info("No files changed")
+		return nil
+	}
+
+	sort.Strings(changed)
+	log.Info("Files changed", zap.Strings("files", changed))
+	return nil
+}
+
+func checksums(files []string) (map[string]string, error) {
+	result := make(map[string]string, len(files))
+	for _, file := range files {
+		checksum, err := sha256Checksum(file)
+		if err != nil {
+			return nil, err
+		}
+		result[file] = checksum
+	}
+	return result, nil
+}
@@ -0,0 +1,239 @@
+package golang
+
+import (
+	"context"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/samber/lo"
+	"go.uber.org/zap"
+
+	"github.com/outofforest/build/v2/pkg/helpers"
+	"github.com/outofforest/build/v2/pkg/tools"
+	"github.com/outofforest/build/v2/pkg/types"
+	"github.com/outofforest/libexec"
+	"github.com/outofforest/logger"
+)
+
+// FuzzConfig is the configuration for running fuzz tests.
+type FuzzConfig struct {
+	// Time is the time spent on fuzzing each target.
+	Time time.Duration
+
+	// Tags is go build tags.
+	Tags []string
+}
+
+// DefaultFuzzConfig returns the default configuration for running fuzz tests.
+func DefaultFuzzConfig() FuzzConfig {
+	return FuzzConfig{
+		Time: 30 * time.Second,
+		Tags: []string{"testing"},
+	}
+}
+
+type fuzzTarget struct {
+	Dir  string
+	Name string
+}
+
+// Fuzz runs go fuzz tests. Corpus generated by the fuzzer is stored in the go cache inside the dev dir,
+// so it is reused by subsequent runs. Crashing inputs are stored by go in testdata/fuzz directory of the package.
+func Fuzz(ctx context.Context, deps types.DepsFunc) error {
+	return fuzz(ctx, deps, DefaultFuzzConfig())
+}
+
+// FuzzWithConfig returns command running go fuzz tests using provided config.
+func FuzzWithConfig(config FuzzConfig) types.CommandFunc {
+	return func(ctx context.Context, deps types.DepsFunc) error {
+		return fuzz(ctx, deps, config)
+	}
+}
+
+func fuzz(ctx context.Context, deps types.DepsFunc, config FuzzConfig) error {
+	deps(EnsureGo)
+
+	log := logger.Get(ctx)
+
+	// Fuzzer uses all the CPUs, so modules and targets are processed sequentially.
+	failures := []string{}
+	err := helpers.OnModule("go.mod", func(path string) error {
+		path = lo.Must(filepath.Abs(lo.Must(filepath.EvalSymlinks(path))))
+
+		targets, err := findFuzzTargets(path)
+		if err != nil {
+			return err
+		}
+		if len(targets) == 0 {
+			log.Info("No fuzz targets", zap.String("path", path))
+			return nil
+		}
+
+		for _, target := range targets {
+			pkg := "./" + filepath.ToSlash(lo.Must(filepath.Rel(path, target.Dir)))
+			corpusDir := filepath.Join(target.Dir, "testdata", "fuzz", target.Name)
+			inputsBefore, err := listFiles(corpusDir)
+			if err != nil {
+				return err
+			}
+
+			args := []string{
+				"test",
+				"-run=^$",
+				"-fuzz=^" + target.Name + "$",
+				"-fuzztime=" + config.Time.String(),
+			}
+			if len(config.Tags) != 0 {
+				args = append(args, "-tags="+strings.Join(config.Tags, ","))
+			}
+			args = append(args, pkg)
+
+			log.Info("Running fuzz test", zap.String("path", path), zap.String("package", pkg),
+				zap.String("target", target.Name))
+			cmd := exec.Command(tools.Bin(ctx, "bin/go", tools.PlatformLocal), args...)
+			cmd.Env = env(ctx)
+			cmd.Dir = path
+			fuzzErr := libexec.Exec(ctx, cmd)
+			if ctx.Err() != nil {
+				return errors.WithStack(ctx.Err())
+			}
+			if fuzzErr == nil {
+				continue
+			}
+
+			inputsAfter, err := listFiles(corpusDir)
+			if err != nil {
+				return err
+			}
+			failure := fmt.Sprintf("fuzz target %s in package '%s' of module '%s' failed", target.Name, pkg, path)
+			for _, input := range lo.Without(inputsAfter, inputsBefore...) {
+				failure += fmt.Sprintf("\n  crashing input: %s\n  reproduce with: go test -run=%s/%s %s",
+					filepath.Join(corpusDir, input), target.Name, input, pkg)
+			}
+			failures = append(failures, failure)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if len(failures) > 0 {
+		return errors.Errorf("fuzz tests failed:\n%s", strings.Join(failures, "\n"))
+	}
+	return nil
+}
+
+// findFuzzTargets returns fuzz targets defined in the module, skipping nested modules.
+func findFuzzTargets(modulePath string) ([]fuzzTarget, error) {
+	files, err := moduleGoFiles(modulePath)
+	if err != nil {
+		return nil, err
+	}
+
+	targets := []fuzzTarget{}
+	fset := token.NewFileSet()
+	for _, path := range files {
+		if !strings.HasSuffix(path, "_test.go") {
+			continue
+		}
+
+		file, err := parser.ParseFile(fset, path, nil, parser.SkipObjectResolution)
+		if err != nil {
+			return nil, errors.Wrapf(err, "parsing file '%s' failed", path)
+		}
+		for _, decl := range file.Decls {
+			if name, ok := fuzzTargetName(decl); ok {
+				targets = append(targets, fuzzTarget{
+					Dir:  filepath.Dir(path),
+					Name: name,
+				})
+			}
+		}
+	}
+
+	sort.Slice(targets, func(i, j int) bool {
+		if targets[i].Dir != targets[j].Dir {
+			return targets[i].Dir < targets[j].Dir
+		}
+		return targets[i].Name < targets[j].Name
+	})
+	return targets, nil
+}
+
+// moduleGoFiles returns go files of the module, skipping nested modules and test data.
+func moduleGoFiles(modulePath string) ([]string, error) {
+	files := []string{}
+	err := filepath.WalkDir(modulePath, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		if d.IsDir() {
+			if path == modulePath {
+				return nil
+			}
+			if name := d.Name(); name == "testdata" || name == "vendor" || strings.HasPrefix(name, ".") ||
+				strings.HasPrefix(name, "_") {
+				return filepath.SkipDir
+			}
+			if _, err := os.Stat(filepath.Join(path, "go.mod")); err == nil {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.Type().IsRegular() && strings.HasSuffix(path, ".go") {
+			files = append(files, path)
+		}
+		return nil
+	})
+	return files, err
+}
+
+// fuzzTargetName returns the name of the function if it has form of func FuzzXxx(*testing.F).
+func fuzzTargetName(decl ast.Decl) (string, bool) {
+	fn, ok := decl.(*ast.FuncDecl)
+	if !ok || fn.Recv != nil || !strings.HasPrefix(fn.Name.Name, "Fuzz") {
+		return "", false
+	}
+	if fn.Type.Params == nil || len(fn.Type.Params.List) != 1 || len(fn.Type.Params.List[0].Names) > 1 {
+		return "", false
+	}
+	star, ok := fn.Type.Params.List[0].Type.(*ast.StarExpr)
+	if !ok {
+		return "", false
+	}
+	sel, ok := star.X.(*ast.SelectorExpr)
+	if !ok || sel.Sel.Name != "F" {
+		return "", false
+	}
+	return fn.Name.Name, true
+}
+
+func listFiles(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	switch {
+	case err == nil:
+	case os.IsNotExist(err):
+		return nil, nil
+	default:
+		return nil, errors.WithStack(err)
+	}
+
+	files := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			files = append(files, entry.Name())
+		}
+	}
+	return files, nil
+}
@@ -7,27 +7,36 @@ import (
 	_ "embed"
 	"encoding/hex"
 	"fmt"
+	"io"
 	"io/fs"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"runtime"
 	"strings"
+	"sync"
 	"text/template"
+	"time"
 
 	"github.com/pkg/errors"
 	"github.com/samber/lo"
 	"go.uber.org/zap"
 
-	"github.com/outofforest/build/v2/pkg/helpers"
 	"github.com/outofforest/build/v2/pkg/tools"
 	builddocker "github.com/outofforest/build/v2/pkg/tools/docker"
 	"github.com/outofforest/build/v2/pkg/types"
 	"github.com/outofforest/libexec"
 	"github.com/outofforest/logger"
 	"github.com/outofforest/tools/pkg/tools/docker"
+	"github.com/outofforest/tools/pkg/tools/junit"
+	"github.com/outofforest/tools/pkg/tools/sarif"
+	"github.com/outofforest/tools/pkg/tools/sbom"
 )
 
-const coverageReportDir = "coverage"
+const (
+	coverageReportDir      = "coverage"
+	dockerBuilderImageName = "docker-go-builder"
+)
 
 // BuildConfig is the configuration for building binaries.
 type BuildConfig struct {
@@ -48,19 +57,113 @@ type BuildConfig struct {
 
 	// Tags is go build tags.
 	Tags []string
+
+	// EmbedBuildInfo embeds version, git commit, build time and dirty flag of the repository into the binary.
+	// Values are available at runtime using github.com/outofforest/tools/pkg/buildinfo package.
+	EmbedBuildInfo bool
+
+	// LDVariables are the values set for string variables using -X linker flag, keyed by full variable name.
+	LDVariables map[string]string
+
+	// SBOMFormats are the formats of SBOM documents stored next to the binary.
+	SBOMFormats []sbom.Format
+
+	// BuilderImage is the configuration of the image used to build the binary in docker.
+	BuilderImage BuilderImageConfig
+}
+
+// LintConfig is the configuration for running linter.
+type LintConfig struct {
+	// SARIFPath is the path of the SARIF file findings from all the modules are stored in.
+	// If empty, SARIF file is not produced.
+	SARIFPath string
+
+	// BaseRef is the git reference used to detect changes. If set, only issues introduced since the merge base
+	// of the reference and HEAD are reported and modules without changed go files are skipped.
+	BaseRef string
+
+	// Workspace runs linter in workspace mode using go.work file generated in the repository root.
+	// Otherwise go.work files are ignored.
+	Workspace bool
+
+	// Workers is the maximum number of modules linted concurrently. If zero, number of CPUs is used.
+	Workers int
+}
+
+// TidyConfig is the configuration for tidying up go modules.
+type TidyConfig struct {
+	// Workspace synchronizes dependencies of the modules with go.work file generated in the repository root
+	// before tidying them up.
+	Workspace bool
+
+	// Workers is the maximum number of modules tidied up concurrently. If zero, number of CPUs is used.
+	Workers int
+}
+
+// UnitTestsConfig is the configuration for running unit tests.
+type UnitTestsConfig struct {
+	// Tags is go build tags.
+	Tags []string
+
+	// Race enables race detector.
+	Race bool
+
+	// Shuffle randomizes the execution order of tests.
+	Shuffle bool
+
+	// Short tells long-running tests to shorten their run time.
+	Short bool
+
+	// Timeout is the timeout of tests in each package. If zero, default timeout of go test is used.
+	Timeout time.Duration
+
+	// Run is the regular expression selecting tests to run.
+	Run string
+
+	// Skip is the regular expression selecting tests to skip.
+	Skip string
+
+	// Bench is the regular expression selecting benchmarks to run together with tests.
+	Bench string
+
+	// BenchTime is the time spent on running each benchmark, e.g. "5s" or "100x".
+	BenchTime string
+
+	// Env is the list of additional environment variables in the form KEY=value.
+	Env []string
+
+	// Workspace runs tests in workspace mode using go.work file generated in the repository root.
+	// Otherwise go.work files are ignored.
+	Workspace bool
+
+	// Coverage is the configuration of coverage checks.
+	Coverage CoverageConfig
+
+	// Workers is the maximum number of modules tested concurrently. If zero, number of CPUs is used.
+	Workers int
+}
+
+// DefaultUnitTestsConfig returns the default configuration for running unit tests.
+func DefaultUnitTestsConfig() UnitTestsConfig {
+	return UnitTestsConfig{
+		Tags:    []string{"testing"},
+		Race:    true,
+		Shuffle: true,
+	}
 }
 
 // Generate calls `go generate`.
 func Generate(ctx context.Context, deps types.DepsFunc) error {
 	deps(EnsureGo)
-	log := logger.Get(ctx)
 
-	return helpers.OnModule("go.mod", func(path string) error {
-		log.Info("Running go generate", zap.String("path", path))
+	return onModules(ctx, "go.mod", runtime.NumCPU(), func(ctx context.Context, path string, output io.Writer) error {
+		logger.Get(ctx).Info("Running go generate", zap.String("path", path))
 
 		cmd := exec.Command(tools.Bin(ctx, "bin/go", tools.PlatformLocal), "generate", "./...")
 		cmd.Env = env(ctx)
 		cmd.Dir = path
+		cmd.Stdout = output
+		cmd.Stderr = output
 		if err := libexec.Exec(ctx, cmd); err != nil {
 			return errors.Wrapf(err, "generation failed in module '%s'", path)
 		}
@@ -70,51 +173,212 @@ func Generate(ctx context.Context, deps types.DepsFunc) error {
 
 // Build builds go binary.
 func Build(ctx context.Context, deps types.DepsFunc, config BuildConfig) error {
+	return build(ctx, deps, config, "")
+}
+
+// build builds go binary. If goCache is not empty, it is used as go build cache.
+func build(ctx context.Context, deps types.DepsFunc, config BuildConfig, goCache string) error {
+	if config.EmbedBuildInfo {
+		var err error
+		config, err = embedBuildInfo(ctx, config)
+		if err != nil {
+			return err
+		}
+	}
+
+	var err error
 	if config.Platform.OS == tools.OSDocker {
-		return buildInDocker(ctx, deps, config)
+		err = buildInDocker(ctx, deps, config, goCache)
+	} else {
+		err = buildLocally(ctx, deps, config, goCache)
 	}
-	return buildLocally(ctx, deps, config)
+	if err != nil || len(config.SBOMFormats) == 0 {
+		return err
+	}
+	return storeSBOM(ctx, config)
+}
+
+// BuildMatrix builds go binary for each of the platforms. Binary for each platform is stored
+// under the path returned by PlatformBinOutputPath. Pure go binaries are cross-compiled locally,
+// docker is used only if cgo is enabled.
+func BuildMatrix(ctx context.Context, deps types.DepsFunc, config BuildConfig, platforms ...tools.Platform) error {
+	binOutputPath := config.BinOutputPath
+	for _, platform := range platforms {
+		config.Platform = platform
+		if config.CGOEnabled && platform.OS == tools.OSLinux && platform != tools.PlatformLocal {
+			config.Platform = tools.Platform{OS: tools.OSDocker, Arch: platform.Arch}
+		}
+		config.BinOutputPath = PlatformBinOutputPath(binOutputPath, platform)
+
+		if err := Build(ctx, deps, config); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// PlatformBinOutputPath returns the path where BuildMatrix stores binary built for the platform.
+func PlatformBinOutputPath(binOutputPath string, platform tools.Platform) string {
+	return filepath.Join(filepath.Dir(binOutputPath), goOS(platform)+"-"+platform.Arch, filepath.Base(binOutputPath))
 }
 
 // Lint lints the go code.
 func Lint(ctx context.Context, deps types.DepsFunc) error {
+	return lint(ctx, deps, LintConfig{})
+}
+
+// LintWithConfig returns command linting go code using provided config.
+func LintWithConfig(config LintConfig) types.CommandFunc {
+	return func(ctx context.Context, deps types.DepsFunc) error {
+		return lint(ctx, deps, config)
+	}
+}
+
+func lint(ctx context.Context, deps types.DepsFunc, config LintConfig) error {
 	deps(EnsureGo, EnsureGolangCI, storeLintConfig)
 
-	log := logger.Get(ctx)
-	config := lintConfigPath(ctx)
+	repoDir := lo.Must(filepath.Abs("."))
+
+	envs, err := workspaceEnv(ctx, config.Workspace)
+	if err != nil {
+		return err
+	}
 
-	return helpers.OnModule("go.mod", func(path string) error {
+	var changed []string
+	var mergeBase string
+	if config.BaseRef != "" {
+		var err error
+		changed, err = changedFiles(ctx, config.BaseRef)
+		if err != nil {
+			return err
+		}
+		mergeBase, err = gitOutput(ctx, ".", "merge-base", config.BaseRef, "HEAD")
+		if err != nil {
+			return err
+		}
+	}
+
+	var mu sync.Mutex
+	var findings []sarif.Finding
+	lintErr := onModules(ctx, "go.mod", config.Workers, func(ctx context.Context, path string, output io.Writer) error {
 		goCodePresent, err := containsGoCode(path)
 		if err != nil {
 			return err
 		}
 		if !goCodePresent {
-			log.Info("No code to lint", zap.String("path", path))
+			logger.Get(ctx).Info("No code to lint", zap.String("path", path))
 			return nil
 		}
 
-		log.Info("Running linter", zap.String("path", path))
-		cmd := exec.Command(tools.Bin(ctx, "bin/golangci-lint", tools.PlatformLocal), "run", "--config", config)
-		cmd.Env = env(ctx)
-		cmd.Dir = path
-		if err := libexec.Exec(ctx, cmd); err != nil {
-			return errors.Wrapf(err, "linter errors found in module '%s'", path)
+		absPath := lo.Must(filepath.Abs(path))
+		lintConfig, err := moduleLintConfigPath(ctx, repoDir, absPath)
+		if err != nil {
+			return err
+		}
+
+		// Modules are linted concurrently, so golangci-lint must not wait for the lock held by other runners.
+		args := []string{"run", "--config", lintConfig, "--allow-parallel-runners"}
+		if config.BaseRef != "" {
+			modulePath := lo.Must(filepath.EvalSymlinks(absPath))
+			if !lo.ContainsBy(changed, func(file string) bool {
+				return strings.HasSuffix(file, ".go") && belongsToModule(modulePath, file)
+			}) {
+				logger.Get(ctx).Info("No changed go files, skipping", zap.String("path", path))
+				return nil
+			}
+			args = append(args, "--new-from-rev", mergeBase)
+		}
+		baseline, err := readLintBaseline(absPath)
+		if err != nil {
+			return err
+		}
+
+		logger.Get(ctx).Info("Running linter", zap.String("path", path))
+		if config.SARIFPath == "" && baseline == nil {
+			cmd := exec.Command(tools.Bin(ctx, "bin/golangci-lint", tools.PlatformLocal), args...)
+			cmd.Env = envs
+			cmd.Dir = path
+			cmd.Stdout = output
+			cmd.Stderr = output
+			if err := libexec.Exec(ctx, cmd); err != nil {
+				return errors.Wrapf(err, "linter errors found in module '%s'", path)
+			}
+			return nil
+		}
+
+		issues, err := collectLintIssues(ctx, path, args, envs, output)
+		if err != nil {
+			return err
+		}
+		issues = newLintIssues(absPath, baseline, issues)
+		if err := printLintIssues(output, issues); err != nil {
+			return err
+		}
+
+		if config.SARIFPath != "" {
+			moduleFindings, err := sarifFindings(repoDir, absPath, issues)
+			if err != nil {
+				return err
+			}
+
+			mu.Lock()
+			findings = append(findings, moduleFindings...)
+			mu.Unlock()
+		}
+
+		if len(issues) > 0 {
+			return errors.Errorf("%d linter error(s) found in module '%s'", len(issues), path)
 		}
 		return nil
 	})
+
+	if config.SARIFPath != "" {
+		if err := sarif.Store(config.SARIFPath, "golangci-lint", findings); err != nil {
+			return err
+		}
+	}
+	return lintErr
 }
 
 // Tidy runs go mod tidy in repository.
 func Tidy(ctx context.Context, deps types.DepsFunc) error {
+	return tidy(ctx, deps, TidyConfig{})
+}
+
+// TidyWithConfig returns command tidying up go modules using provided config.
+func TidyWithConfig(config TidyConfig) types.CommandFunc {
+	return func(ctx context.Context, deps types.DepsFunc) error {
+		return tidy(ctx, deps, config)
+	}
+}
+
+func tidy(ctx context.Context, deps types.DepsFunc, config TidyConfig) error {
 	deps(EnsureGo)
 
 	log := logger.Get(ctx)
-	return helpers.OnModule("go.mod", func(path string) error {
-		log.Info("Running go mod tidy", zap.String("path", path))
+
+	if config.Workspace {
+		envs, err := workspaceEnv(ctx, true)
+		if err != nil {
+			return err
+		}
+
+		log.Info("Running go work sync")
+		cmd := exec.Command(tools.Bin(ctx, "bin/go", tools.PlatformLocal), "work", "sync")
+		cmd.Env = envs
+		if err := libexec.Exec(ctx, cmd); err != nil {
+			return errors.Wrap(err, "'go work sync' failed")
+		}
+	}
+
+	return onModules(ctx, "go.mod", config.Workers, func(ctx context.Context, path string, output io.Writer) error {
+		logger.Get(ctx).Info("Running go mod tidy", zap.String("path", path))
 
 		cmd := exec.Command(tools.Bin(ctx, "bin/go", tools.PlatformLocal), "mod", "tidy")
 		cmd.Env = env(ctx)
 		cmd.Dir = path
+		cmd.Stdout = output
+		cmd.Stderr = output
 		if err := libexec.Exec(ctx, cmd); err != nil {
 			return errors.Wrapf(err, "'go mod tidy' failed in module '%s'", path)
 		}
@@ -124,16 +388,36 @@ func Tidy(ctx context.Context, deps types.DepsFunc) error {
 
 // UnitTests runs go unit tests in repository.
 func UnitTests(ctx context.Context, deps types.DepsFunc) error {
+	return unitTests(ctx, deps, DefaultUnitTestsConfig(), nil)
+}
+
+// UnitTestsWithConfig returns command running go unit tests using provided config.
+func UnitTestsWithConfig(config UnitTestsConfig) types.CommandFunc {
+	return func(ctx context.Context, deps types.DepsFunc) error {
+		return unitTests(ctx, deps, config, nil)
+	}
+}
+
+// unitTests runs unit tests. If packages is not nil, only packages listed for the module are tested.
+func unitTests(ctx context.Context, deps types.DepsFunc, config UnitTestsConfig, packages map[string][]string) error {
 	deps(EnsureGo)
 
-	log := logger.Get(ctx)
+	envs, err := workspaceEnv(ctx, config.Workspace)
+	if err != nil {
+		return err
+	}
 
 	covDir := lo.Must(filepath.Abs(coverageReportDir))
 	if err := os.MkdirAll(covDir, 0o700); err != nil {
 		return errors.WithStack(err)
 	}
-	rootDir := filepath.Dir(lo.Must(filepath.Abs(lo.Must(filepath.EvalSymlinks(lo.Must(os.Getwd()))))))
-	return helpers.OnModule("go.mod", func(path string) error {
+	resultsDir := lo.Must(filepath.Abs(junit.ReportDir))
+	repoDir := lo.Must(filepath.Abs(lo.Must(filepath.EvalSymlinks(lo.Must(os.Getwd())))))
+	rootDir := filepath.Dir(repoDir)
+
+	var mu sync.Mutex
+	profiles := []moduleCoverage{}
+	err = onModules(ctx, "go.mod", config.Workers, func(ctx context.Context, path string, output io.Writer) error {
 		path = lo.Must(filepath.Abs(lo.Must(filepath.EvalSymlinks(path))))
 		relPath, err := filepath.Rel(rootDir, path)
 		if err != nil {
@@ -145,33 +429,95 @@ func UnitTests(ctx context.Context, deps types.DepsFunc) error {
 			return err
 		}
 		if !goCodePresent {
-			log.Info("No code to test", zap.String("path", path))
+			logger.Get(ctx).Info("No code to test", zap.String("path", path))
 			return nil
 		}
 
+		pkgs := []string{"./..."}
+		if packages != nil {
+			pkgs = packages[path]
+			if len(pkgs) == 0 {
+				logger.Get(ctx).Info("No affected packages to test", zap.String("path", path))
+				return nil
+			}
+		}
+
 		coverageName := strings.ReplaceAll(relPath, "/", "-")
 		coverageProfile := filepath.Join(covDir, coverageName)
 
-		log.Info("Running go tests", zap.String("path", path))
-		cmd := exec.Command(
-			tools.Bin(ctx, "bin/go", tools.PlatformLocal),
-			"test",
-			"-tags=testing",
-			"-count=1",
-			"-shuffle=on",
-			"-race",
-			"-cover", "./...",
-			"-coverpkg", "./...",
-			"-coverprofile", coverageProfile,
-			"./...",
-		)
-		cmd.Env = env(ctx)
+		logger.Get(ctx).Info("Running go tests", zap.String("path", path))
+		cmd := exec.Command(tools.Bin(ctx, "bin/go", tools.PlatformLocal),
+			unitTestsArgs(config, coverageProfile, pkgs)...)
+		results := newTestResultsWriter(output)
+		cmd.Env = append(append([]string{}, envs...), config.Env...)
 		cmd.Dir = path
-		if err := libexec.Exec(ctx, cmd); err != nil {
-			return errors.Wrapf(err, "unit tests failed in module '%s'", path)
+		cmd.Stdout = results
+		cmd.Stderr = output
+		testErr := libexec.Exec(ctx, cmd)
+		if err := results.Flush(); err != nil {
+			return errors.WithStack(err)
+		}
+		if err := junit.Store(resultsDir, coverageName, results.Report(relPath)); err != nil {
+			return err
 		}
+		if testErr != nil {
+			return errors.Wrapf(testErr, "unit tests failed in module '%s'", path)
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		profiles = append(profiles, moduleCoverage{
+			Module:     lo.Must(filepath.Rel(repoDir, path)),
+			ModulePath: path,
+			Path:       coverageProfile,
+		})
 		return nil
 	})
+	if err != nil {
+		return err
+	}
+
+	return reportCoverage(ctx, covDir, profiles, config.Coverage)
+}
+
+func unitTestsArgs(config UnitTestsConfig, coverageProfile string, pkgs []string) []string {
+	args := []string{
+		"test",
+		"-json",
+		"-count=1",
+		"-cover",
+		"-coverpkg", "./...",
+		"-coverprofile", coverageProfile,
+	}
+	if len(config.Tags) != 0 {
+		args = append(args, "-tags="+strings.Join(config.Tags, ","))
+	}
+	if config.Race {
+		args = append(args, "-race")
+	}
+	if config.Shuffle {
+		args = append(args, "-shuffle=on")
+	}
+	if config.Short {
+		args = append(args, "-short")
+	}
+	if config.Timeout != 0 {
+		args = append(args, "-timeout="+config.Timeout.String())
+	}
+	if config.Run != "" {
+		args = append(args, "-run="+config.Run)
+	}
+	if config.Skip != "" {
+		args = append(args, "-skip="+config.Skip)
+	}
+	if config.Bench != "" {
+		args = append(args, "-bench="+config.Bench)
+	}
+	if config.BenchTime != "" {
+		args = append(args, "-benchtime="+config.BenchTime)
+	}
+	return append(args, pkgs...)
 }
 
 //go:embed Dockerfile.builder.tmpl
@@ -179,37 +525,47 @@ var dockerfileBuilderTemplate string
 
 var dockerfileBuilderTemplateParsed = template.Must(template.New("").Parse(dockerfileBuilderTemplate))
 
+// BuilderImageConfig is the configuration of the image used to build go binaries in docker.
+type BuilderImageConfig struct {
+	// Packages are additional alpine packages installed in the image, e.g. C libraries required by cgo.
+	Packages []string
+
+	// Steps are additional Dockerfile instructions executed at the end.
+	Steps []string
+
+	// Template is the Dockerfile template replacing the default one. It receives the same data as the default
+	// template: Arch, GOVersion, AlpineVersion, Packages and Steps.
+	Template string
+}
+
 // DockerBuilderImage creates the image used to build go binaries and returns it name.
 func DockerBuilderImage(ctx context.Context, deps types.DepsFunc, platform tools.Platform) (string, error) {
+	return DockerBuilderImageWithConfig(ctx, deps, platform, BuilderImageConfig{})
+}
+
+// DockerBuilderImageWithConfig creates the customized image used to build go binaries and returns it name.
+// Image is tagged using the hash of the rendered Dockerfile, so each variant is cached separately.
+func DockerBuilderImageWithConfig(
+	ctx context.Context,
+	deps types.DepsFunc,
+	platform tools.Platform,
+	config BuilderImageConfig,
+) (string, error) {
 	if platform.OS != tools.OSDocker {
 		return "", errors.Errorf("docker platform must be specified, %s provided", platform)
 	}
 
 	deps(builddocker.EnsureDocker)
 
-	const imageName = "docker-go-builder"
-
 	goTool, err := tools.Get(Go)
 	if err != nil {
 		return "", err
 	}
-	dockerfileBuf := &bytes.Buffer{}
-	err = dockerfileBuilderTemplateParsed.Execute(dockerfileBuf, struct {
-		Arch          string
-		GOVersion     string
-		AlpineVersion string
-	}{
-		Arch:          platform.Arch,
-		GOVersion:     goTool.GetVersion(),
-		AlpineVersion: docker.AlpineVersion,
-	})
+	image, dockerfile, err := renderBuilderImage(platform, config)
 	if err != nil {
-		return "", errors.Wrap(err, "executing Dockerfile template failed")
+		return "", err
 	}
 
-	dockerfileChecksum := sha256.Sum256(dockerfileBuf.Bytes())
-	image := imageName + ":" + hex.EncodeToString(dockerfileChecksum[:4])
-
 	imageBuf := &bytes.Buffer{}
 	imageCmd := exec.Command("docker", "images", "-q", image)
 	imageCmd.Stdout = imageBuf
@@ -224,10 +580,11 @@ func DockerBuilderImage(ctx context.Context, deps types.DepsFunc, platform tools
 		"docker",
 		"build",
 		"--label", builddocker.LabelKey+"="+builddocker.LabelValue,
+		"--label", docker.ToolLabelKey+"="+docker.ToolLabel(Go, goTool.GetVersion()),
 		"--tag", image,
 		"-",
 	)
-	buildCmd.Stdin = dockerfileBuf
+	buildCmd.Stdin = bytes.NewReader(dockerfile)
 
 	if err := libexec.Exec(ctx, buildCmd); err != nil {
 		return "", errors.Wrapf(err, "failed to build image '%s'", image)
@@ -235,23 +592,81 @@ func DockerBuilderImage(ctx context.Context, deps types.DepsFunc, platform tools
 	return image, nil
 }
 
-func buildLocally(ctx context.Context, deps types.DepsFunc, config BuildConfig) error {
+// DockerBuilderImages returns references of the images used to build go binaries in docker for all the docker
+// platforms using provided configs.
+func DockerBuilderImages(configs ...BuilderImageConfig) ([]string, error) {
+	images := []string{}
+	for _, config := range configs {
+		for _, platform := range []tools.Platform{tools.PlatformDockerAMD64, tools.PlatformDockerARM64} {
+			image, _, err := renderBuilderImage(platform, config)
+			if err != nil {
+				return nil, err
+			}
+			images = append(images, image)
+		}
+	}
+	return images, nil
+}
+
+// renderBuilderImage renders Dockerfile of the image used to build go binaries and returns the reference
+// of the image together with the Dockerfile.
+func renderBuilderImage(platform tools.Platform, config BuilderImageConfig) (string, []byte, error) {
+	dockerfileTemplate := dockerfileBuilderTemplateParsed
+	if config.Template != "" {
+		var err error
+		dockerfileTemplate, err = template.New("").Parse(config.Template)
+		if err != nil {
+			return "", nil, errors.Wrap(err, "parsing Dockerfile template failed")
+		}
+	}
+
+	goTool, err := tools.Get(Go)
+	if err != nil {
+		return "", nil, err
+	}
+	dockerfileBuf := &bytes.Buffer{}
+	err = dockerfileTemplate.Execute(dockerfileBuf, struct {
+		Arch          string
+		GOVersion     string
+		AlpineVersion string
+		Packages      []string
+		Steps         []string
+	}{
+		Arch:          platform.Arch,
+		GOVersion:     goTool.GetVersion(),
+		AlpineVersion: docker.AlpineVersion,
+		Packages:      config.Packages,
+		Steps:         config.Steps,
+	})
+	if err != nil {
+		return "", nil, errors.Wrap(err, "executing Dockerfile template failed")
+	}
+
+	dockerfileChecksum := sha256.Sum256(dockerfileBuf.Bytes())
+	return dockerBuilderImageName + ":" + hex.EncodeToString(dockerfileChecksum[:4]), dockerfileBuf.Bytes(), nil
+}
+
+func buildLocally(ctx context.Context, deps types.DepsFunc, config BuildConfig, goCache string) error {
 	deps(EnsureGo)
 
-	if config.Platform != tools.PlatformLocal {
-		return errors.Errorf("building requested for platform %s while only %s is supported",
+	if config.CGOEnabled && config.Platform != tools.PlatformLocal {
+		return errors.Errorf("building cgo binary requested for platform %s while only %s is supported",
 			config.Platform, tools.PlatformLocal)
 	}
 
-	args, envs := buildArgsAndEnvs(ctx, config)
+	args, envs, err := buildArgsAndEnvs(ctx, config, goCache)
+	if err != nil {
+		return err
+	}
 
-	cmd := exec.Command(tools.Bin(ctx, "bin/go", config.Platform), args...)
+	cmd := exec.Command(tools.Bin(ctx, "bin/go", tools.PlatformLocal), args...)
 	cmd.Dir = config.PackagePath
 	cmd.Env = append(os.Environ(), envs...)
 
 	logger.Get(ctx).Info(
 		"Building go package locally",
 		zap.String("package", config.PackagePath),
+		zap.String("platform", config.Platform.String()),
 		zap.String("output", config.BinOutputPath),
 		zap.String("command", cmd.String()),
 	)
@@ -261,10 +676,10 @@ func buildLocally(ctx context.Context, deps types.DepsFunc, config BuildConfig)
 	return nil
 }
 
-func buildInDocker(ctx context.Context, deps types.DepsFunc, config BuildConfig) error {
+func buildInDocker(ctx context.Context, deps types.DepsFunc, config BuildConfig, goCache string) error {
 	deps(builddocker.EnsureDocker)
 
-	image, err := DockerBuilderImage(ctx, deps, config.Platform)
+	image, err := DockerBuilderImageWithConfig(ctx, deps, config.Platform, config.BuilderImage)
 	if err != nil {
 		return err
 	}
@@ -276,18 +691,34 @@ func buildInDocker(ctx context.Context, deps types.DepsFunc, config BuildConfig)
 		return errors.WithStack(err)
 	}
 
-	args, envs := buildArgsAndEnvs(ctx, config)
+	cacheDir := dockerCacheDir(ctx, config.Platform)
+	modCacheDir := filepath.Join(cacheDir, "mod")
+	buildCacheDir := filepath.Join(cacheDir, "build")
+	for _, dir := range []string{modCacheDir, buildCacheDir} {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+
+	if goCache == "" {
+		goCache = buildCacheDir
+	}
+	args, envs, err := buildArgsAndEnvs(ctx, config, goCache)
 	if err != nil {
 		return err
 	}
+	envs = withEnv(envs, "GOMODCACHE="+modCacheDir, "GOFLAGS=-modcacherw")
+
+	containerName := docker.ContainerName("outofforest-build-golang")
 	runArgs := []string{
 		"run", "--rm",
 		"--label", builddocker.LabelKey + "=" + builddocker.LabelValue,
 		"-v", srcDir + ":" + srcDir,
 		"-v", envDir + ":" + envDir,
+		"-v", cacheDir + ":" + cacheDir,
 		"--workdir", filepath.Join(srcDir, config.PackagePath),
 		"--user", fmt.Sprintf("%d:%d", os.Getuid(), os.Getgid()),
-		"--name", "outofforest-build-golang",
+		"--name", containerName,
 	}
 
 	for _, env := range envs {
@@ -303,17 +734,24 @@ func buildInDocker(ctx context.Context, deps types.DepsFunc, config BuildConfig)
 		zap.String("package", config.PackagePath),
 		zap.String("command", cmd.String()),
 	)
-	if err := libexec.Exec(ctx, cmd); err != nil {
+	if err := docker.RunContainer(ctx, containerName, cmd); err != nil {
 		return errors.Wrapf(err, "building package '%s' failed", config.PackagePath)
 	}
 	return nil
 }
 
-func buildArgsAndEnvs(ctx context.Context, config BuildConfig) (args, envs []string) {
+// buildArgsAndEnvs returns arguments and environment variables of go build. If goCache is not empty,
+// it is used as go build cache.
+func buildArgsAndEnvs(ctx context.Context, config BuildConfig, goCache string) (args, envs []string, err error) {
 	ldFlags := []string{"-w", "-s"}
 	if config.StaticBuild && config.Platform.OS == tools.OSDocker {
 		ldFlags = append(ldFlags, "-extldflags=-static")
 	}
+	variableFlags, err := ldVariableFlags(config.LDVariables)
+	if err != nil {
+		return nil, nil, err
+	}
+	ldFlags = append(ldFlags, variableFlags...)
 
 	args = []string{
 		"build",
@@ -327,22 +765,38 @@ func buildArgsAndEnvs(ctx context.Context, config BuildConfig) (args, envs []str
 		args = append(args, "-tags="+strings.Join(config.Tags, ","))
 	}
 
-	goOS := config.Platform.OS
-	if goOS == tools.OSDocker {
-		goOS = tools.OSLinux
-	}
-
 	cgoEnabled := "0"
 	if config.CGOEnabled {
 		cgoEnabled = "1"
 	}
 	envs = append(env(ctx),
 		"CGO_ENABLED="+cgoEnabled,
-		"GOOS="+goOS,
+		"GOOS="+goOS(config.Platform),
 		"GOARCH="+config.Platform.Arch,
 	)
+	if goCache != "" {
+		envs = withEnv(envs, "GOCACHE="+goCache)
+	}
 
-	return args, envs
+	return args, envs, nil
+}
+
+// withEnv sets environment variables, replacing existing values.
+func withEnv(envs []string, vars ...string) []string {
+	for _, v := range vars {
+		key, _, _ := strings.Cut(v, "=")
+		envs = append(lo.Filter(envs, func(env string, _ int) bool {
+			return !strings.HasPrefix(env, key+"=")
+		}), v)
+	}
+	return envs
+}
+
+func goOS(platform tools.Platform) string {
+	if platform.OS == tools.OSDocker {
+		return tools.OSLinux
+	}
+	return platform.OS
 }
 
 func containsGoCode(path string) (bool, error) {
@@ -0,0 +1,183 @@
+package golang
+
+import (
+	"bytes"
+	"context"
+	_ "embed"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/pkg/errors"
+	"github.com/samber/lo"
+	"go.uber.org/zap"
+
+	"github.com/outofforest/build/v2/pkg/helpers"
+	"github.com/outofforest/build/v2/pkg/tools"
+	builddocker "github.com/outofforest/build/v2/pkg/tools/docker"
+	"github.com/outofforest/build/v2/pkg/types"
+	"github.com/outofforest/libexec"
+	"github.com/outofforest/logger"
+	"github.com/outofforest/tools/pkg/tools/docker"
+)
+
+// Base images.
+const (
+	BaseImageAlpine  = "alpine:" + docker.AlpineVersion
+	BaseImageScratch = "scratch"
+)
+
+// buildxBuilder is the name of buildx builder used to build images. Docker driver can't export OCI images,
+// so builder using docker-container driver is created.
+const buildxBuilder = "outofforest-build"
+
+//go:embed Dockerfile.image.tmpl
+var dockerfileImageTemplate string
+
+var dockerfileImageTemplateParsed = template.Must(template.New("Dockerfile").Parse(dockerfileImageTemplate))
+
+// ImageConfig is the configuration for building container image.
+type ImageConfig struct {
+	// Name is the name of the image, including the tag.
+	Name string
+
+	// BaseImage is the image binaries are added to. If empty, BaseImageAlpine is used.
+	BaseImage string
+
+	// Binaries are the paths of binaries, as passed in BinOutputPath to BuildMatrix. Binary for each platform
+	// is taken from the path returned by PlatformBinOutputPath. Binaries are stored in /usr/local/bin.
+	Binaries []string
+
+	// Entrypoint is the name of the binary used as the entrypoint. If empty, the first binary is used.
+	Entrypoint string
+
+	// Platforms are the linux platforms to build the image for. If empty, amd64 and arm64 are used.
+	Platforms []tools.Platform
+
+	// OCIPath is the path of OCI tarball the image is exported to. If empty, image is loaded to docker,
+	// which, for multi-platform images, requires docker to use containerd image store.
+	OCIPath string
+}
+
+// BuildImage builds container image containing go binaries.
+func BuildImage(ctx context.Context, deps types.DepsFunc, config ImageConfig) error {
+	deps(builddocker.EnsureDocker)
+
+	if config.Name == "" {
+		return errors.New("image name must be specified")
+	}
+	if len(config.Binaries) == 0 {
+		return errors.New("no binaries specified for the image")
+	}
+	if config.BaseImage == "" {
+		config.BaseImage = BaseImageAlpine
+	}
+	if len(config.Platforms) == 0 {
+		config.Platforms = []tools.Platform{
+			tools.PlatformLinuxAMD64,
+			{OS: tools.OSLinux, Arch: "arm64"},
+		}
+	}
+
+	contextDir, err := os.MkdirTemp("", "image-")
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer os.RemoveAll(contextDir)
+
+	binaries := make([]string, 0, len(config.Binaries))
+	platforms := make([]string, 0, len(config.Platforms))
+	for _, platform := range config.Platforms {
+		platforms = append(platforms, goOS(platform)+"/"+platform.Arch)
+	}
+	for _, binary := range config.Binaries {
+		name := filepath.Base(binary)
+		binaries = append(binaries, name)
+		for _, platform := range config.Platforms {
+			src := PlatformBinOutputPath(binary, platform)
+			if _, err := os.Stat(src); err != nil {
+				return errors.Wrapf(err, "binary '%s' for platform %s does not exist", binary, platform)
+			}
+			if err := helpers.CopyFile(PlatformBinOutputPath(filepath.Join(contextDir, name), platform), src,
+				0o755); err != nil {
+				return err
+			}
+		}
+	}
+	if config.Entrypoint == "" {
+		config.Entrypoint = binaries[0]
+	}
+
+	dockerfile := filepath.Join(contextDir, "Dockerfile")
+	dockerfileBuf := &bytes.Buffer{}
+	if err := dockerfileImageTemplateParsed.Execute(dockerfileBuf, struct {
+		BaseImage  string
+		Binaries   []string
+		Entrypoint string
+	}{
+		BaseImage:  config.BaseImage,
+		Binaries:   binaries,
+		Entrypoint: config.Entrypoint,
+	}); err != nil {
+		return errors.Wrap(err, "executing Dockerfile template failed")
+	}
+	if err := os.WriteFile(dockerfile, dockerfileBuf.Bytes(), 0o600); err != nil {
+		return errors.WithStack(err)
+	}
+
+	if err := ensureBuildxBuilder(ctx); err != nil {
+		return err
+	}
+
+	args := []string{
+		"buildx", "build",
+		"--builder", buildxBuilder,
+		"--platform", strings.Join(platforms, ","),
+		"--label", builddocker.LabelKey + "=" + builddocker.LabelValue,
+		"--tag", config.Name,
+		"--file", dockerfile,
+	}
+	if config.OCIPath != "" {
+		ociPath := lo.Must(filepath.Abs(config.OCIPath))
+		if err := os.MkdirAll(filepath.Dir(ociPath), 0o700); err != nil {
+			return errors.WithStack(err)
+		}
+		args = append(args, "--output", "type=oci,dest="+ociPath)
+	} else {
+		args = append(args, "--load")
+	}
+
+	cmd := exec.Command("docker", append(args, contextDir)...)
+	logger.Get(ctx).Info("Building image",
+		zap.String("image", config.Name),
+		zap.Strings("platforms", platforms),
+		zap.String("command", cmd.String()))
+	if err := libexec.Exec(ctx, cmd); err != nil {
+		return errors.Wrapf(err, "building image '%s' failed", config.Name)
+	}
+	return nil
+}
+
+func ensureBuildxBuilder(ctx context.Context) error {
+	cmd := exec.Command("docker", "buildx", "inspect", buildxBuilder)
+	cmd.Stdout = &bytes.Buffer{}
+	cmd.Stderr = &bytes.Buffer{}
+	if err := libexec.Exec(ctx, cmd); err == nil {
+		return nil
+	}
+
+	stderr := &bytes.Buffer{}
+	cmd = exec.Command("docker", "buildx", "create", "--name", buildxBuilder, "--driver", "docker-container")
+	cmd.Stderr = stderr
+	if err := libexec.Exec(ctx, cmd); err != nil {
+		// Builder might be created concurrently by another build.
+		if strings.Contains(stderr.String(), "already exists") || strings.Contains(stderr.String(),
+			"existing instance") {
+			return nil
+		}
+		return errors.Wrapf(err, "creating buildx builder '%s' failed: %s", buildxBuilder, stderr)
+	}
+	return nil
+}
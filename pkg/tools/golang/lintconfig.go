@@ -0,0 +1,179 @@
+package golang
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+	"github.com/samber/lo"
+	"gopkg.in/yaml.v3"
+
+	"github.com/outofforest/build/v2/pkg/helpers"
+	"github.com/outofforest/build/v2/pkg/tools"
+	"github.com/outofforest/build/v2/pkg/types"
+)
+
+// LintConfigOverlayFile is the name of the file overlaying golangci-lint configuration. It might be placed
+// in the root directory of the repository and in the directory of the module. Overlays are deep-merged
+// into the embedded configuration, first the repository one, then the module one.
+const LintConfigOverlayFile = ".golangci-overlay.yaml"
+
+// lintConfigAppendLists are the lists which are appended instead of being replaced when overlay is merged.
+var lintConfigAppendLists = map[string]bool{
+	"run.build-tags":       true,
+	"issues.exclude":       true,
+	"issues.exclude-dirs":  true,
+	"issues.exclude-files": true,
+	"issues.exclude-rules": true,
+}
+
+// PrintLintConfig prints effective linter configuration of each module.
+func PrintLintConfig(ctx context.Context, deps types.DepsFunc) error {
+	repoDir := lo.Must(filepath.Abs("."))
+	return helpers.OnModule("go.mod", func(path string) error {
+		config, _, err := effectiveLintConfig(repoDir, lo.Must(filepath.Abs(path)))
+		if err != nil {
+			return err
+		}
+		fmt.Printf("# module: %s\n%s\n", path, config)
+		return nil
+	})
+}
+
+// moduleLintConfigPath returns the path to the linter configuration file used for the module.
+func moduleLintConfigPath(ctx context.Context, repoDir, modulePath string) (string, error) {
+	config, overlaid, err := effectiveLintConfig(repoDir, modulePath)
+	if err != nil {
+		return "", err
+	}
+	if !overlaid {
+		return lintConfigPath(ctx), nil
+	}
+
+	checksum := sha256.Sum256(config)
+	configPath := filepath.Join(tools.VersionDir(ctx, tools.PlatformLocal),
+		"golangci-"+hex.EncodeToString(checksum[:4])+".yaml")
+
+	// Many modules might use the same config, so it is written atomically.
+	tmpFile, err := os.CreateTemp(filepath.Dir(configPath), "golangci-*.yaml")
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	defer os.Remove(tmpFile.Name())
+	defer tmpFile.Close()
+
+	if _, err := tmpFile.Write(config); err != nil {
+		return "", errors.WithStack(err)
+	}
+	if err := tmpFile.Close(); err != nil {
+		return "", errors.WithStack(err)
+	}
+	if err := os.Rename(tmpFile.Name(), configPath); err != nil {
+		return "", errors.WithStack(err)
+	}
+	return configPath, nil
+}
+
+// effectiveLintConfig returns linter configuration with repository and module overlays applied.
+func effectiveLintConfig(repoDir, modulePath string) ([]byte, bool, error) {
+	overlayPaths := []string{filepath.Join(repoDir, LintConfigOverlayFile)}
+	if modulePath != repoDir {
+		overlayPaths = append(overlayPaths, filepath.Join(modulePath, LintConfigOverlayFile))
+	}
+
+	var config map[string]any
+	overlaid := false
+	for _, overlayPath := range overlayPaths {
+		overlayRaw, err := os.ReadFile(overlayPath)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, false, errors.WithStack(err)
+		}
+
+		if config == nil {
+			if err := yaml.Unmarshal(lintConfig, &config); err != nil {
+				return nil, false, errors.Wrap(err, "parsing embedded linter config failed")
+			}
+		}
+
+		var overlay map[string]any
+		if err := yaml.Unmarshal(overlayRaw, &overlay); err != nil {
+			return nil, false, errors.Wrapf(err, "parsing linter config overlay '%s' failed", overlayPath)
+		}
+		config = mergeLintConfig(config, overlay, "")
+		overlaid = true
+	}
+
+	if !overlaid {
+		return lintConfig, false, nil
+	}
+
+	configRaw, err := yaml.Marshal(config)
+	if err != nil {
+		return nil, false, errors.WithStack(err)
+	}
+	return configRaw, true, nil
+}
+
+// mergeLintConfig deep-merges overlay into the base config. Linters enabled by the overlay are removed from
+// the disabled ones and vice versa. If all linters are enabled, list of enabled ones is dropped, the same
+// applies to disabled ones.
+func mergeLintConfig(base, overlay map[string]any, prefix string) map[string]any {
+	result := make(map[string]any, len(base))
+	for k, v := range base {
+		result[k] = v
+	}
+
+	for k, v := range overlay {
+		path := k
+		if prefix != "" {
+			path = prefix + "." + k
+		}
+
+		baseMap, baseIsMap := result[k].(map[string]any)
+		overlayMap, overlayIsMap := v.(map[string]any)
+		baseList, baseIsList := result[k].([]any)
+		overlayList, overlayIsList := v.([]any)
+
+		switch {
+		case path == "linters.enable" || path == "linters.disable":
+		case baseIsMap && overlayIsMap:
+			result[k] = mergeLintConfig(baseMap, overlayMap, path)
+		case baseIsList && overlayIsList && lintConfigAppendLists[path]:
+			result[k] = append(append([]any{}, baseList...), overlayList...)
+		default:
+			result[k] = v
+		}
+	}
+
+	if prefix == "linters" {
+		baseEnable, _ := base["enable"].([]any)
+		baseDisable, _ := base["disable"].([]any)
+		overlayEnable, _ := overlay["enable"].([]any)
+		overlayDisable, _ := overlay["disable"].([]any)
+
+		// Golangci-lint rejects lists of enabled or disabled linters combined with enable-all or disable-all.
+		// Those linters are already enabled or disabled then, so it is enough to remove them from the other list.
+		setLintersList(result, "enable", lo.Uniq(append(lo.Without(baseEnable, overlayDisable...),
+			overlayEnable...)), result["enable-all"] == true)
+		setLintersList(result, "disable", lo.Uniq(append(lo.Without(baseDisable, overlayEnable...),
+			overlayDisable...)), result["disable-all"] == true)
+	}
+
+	return result
+}
+
+// setLintersList sets the list of linters under the key, the key is removed if the list is redundant.
+func setLintersList(config map[string]any, key string, linters []any, all bool) {
+	if all || len(linters) == 0 {
+		delete(config, key)
+		return
+	}
+	config[key] = linters
+}
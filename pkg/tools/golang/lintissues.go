@@ -0,0 +1,128 @@
+package golang
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+
+	"github.com/outofforest/build/v2/pkg/tools"
+	"github.com/outofforest/libexec"
+	"github.com/outofforest/tools/pkg/tools/sarif"
+)
+
+type lintIssue struct {
+	FromLinter string
+	Text       string
+	Severity   string
+	Pos        lintIssuePosition
+}
+
+type lintIssuePosition struct {
+	Filename string
+	Line     int
+	Column   int
+}
+
+// collectLintIssues runs golangci-lint in the module and returns all the reported issues.
+func collectLintIssues(
+	ctx context.Context,
+	modulePath string,
+	args, envs []string,
+	output io.Writer,
+) ([]lintIssue, error) {
+	f, err := os.CreateTemp("", "golangci-*.json")
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	issuesFile := f.Name()
+	defer os.Remove(issuesFile)
+
+	if err := f.Close(); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	cmd := exec.Command(tools.Bin(ctx, "bin/golangci-lint", tools.PlatformLocal), append(append([]string{}, args...),
+		"--out-format", "json:"+issuesFile,
+		"--issues-exit-code", "0",
+		"--max-issues-per-linter", "0",
+		"--max-same-issues", "0",
+	)...)
+	cmd.Env = envs
+	cmd.Dir = modulePath
+	cmd.Stdout = output
+	cmd.Stderr = output
+	if err := libexec.Exec(ctx, cmd); err != nil {
+		return nil, errors.Wrapf(err, "linter failed in module '%s'", modulePath)
+	}
+
+	return readLintIssues(issuesFile)
+}
+
+// printLintIssues prints issues in the format used by golangci-lint.
+func printLintIssues(output io.Writer, issues []lintIssue) error {
+	for _, issue := range issues {
+		if _, err := fmt.Fprintf(output, "%s:%d:%d: %s (%s)\n", issue.Pos.Filename, issue.Pos.Line, issue.Pos.Column,
+			issue.Text, issue.FromLinter); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	return nil
+}
+
+// readLintIssues reads issues stored by golangci-lint in json format.
+func readLintIssues(file string) ([]lintIssue, error) {
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if len(content) == 0 {
+		return nil, nil
+	}
+
+	var report struct {
+		Issues []lintIssue
+	}
+	if err := json.Unmarshal(content, &report); err != nil {
+		return nil, errors.Wrapf(err, "parsing linter report '%s' failed", file)
+	}
+	return report.Issues, nil
+}
+
+// sarifFindings converts issues reported in the module to SARIF findings with paths relative to the repository root.
+func sarifFindings(repoDir, modulePath string, issues []lintIssue) ([]sarif.Finding, error) {
+	findings := make([]sarif.Finding, 0, len(issues))
+	for _, issue := range issues {
+		file := issue.Pos.Filename
+		if !filepath.IsAbs(file) {
+			file = filepath.Join(modulePath, file)
+		}
+		file, err := filepath.Rel(repoDir, file)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+
+		level := sarif.LevelError
+		switch issue.Severity {
+		case "warning":
+			level = sarif.LevelWarning
+		case "info":
+			level = sarif.LevelNote
+		}
+
+		findings = append(findings, sarif.Finding{
+			RuleID:  issue.FromLinter,
+			Level:   level,
+			Message: issue.Text,
+			File:    file,
+			Line:    issue.Pos.Line,
+			Column:  issue.Pos.Column,
+		})
+	}
+	return findings, nil
+}
@@ -0,0 +1,370 @@
+package golang
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/samber/lo"
+	"go.uber.org/zap"
+
+	"github.com/outofforest/build/v2/pkg/helpers"
+	"github.com/outofforest/build/v2/pkg/tools"
+	"github.com/outofforest/build/v2/pkg/types"
+	"github.com/outofforest/libexec"
+	"github.com/outofforest/logger"
+)
+
+// DepsCheckConfig is the configuration for checking consistency of go.mod files across modules.
+type DepsCheckConfig struct {
+	// Align updates modules to the highest version of each dependency and go directive found in repository,
+	// instead of failing.
+	Align bool
+}
+
+type goModFile struct {
+	Go      string
+	Require []struct {
+		Path    string
+		Version string
+	}
+	Replace []goModReplace
+}
+
+type goModReplace struct {
+	Old struct {
+		Path string
+	}
+}
+
+// modVersions maps version to the modules using it.
+type modVersions map[string][]string
+
+// CheckDeps reports dependencies required at different versions by modules of the repository
+// and mismatching go directives.
+func CheckDeps(ctx context.Context, deps types.DepsFunc) error {
+	return checkDeps(ctx, deps, DepsCheckConfig{})
+}
+
+// AlignDeps aligns dependencies and go directives of all the modules to the highest versions found.
+func AlignDeps(ctx context.Context, deps types.DepsFunc) error {
+	return checkDeps(ctx, deps, DepsCheckConfig{Align: true})
+}
+
+// CheckDepsWithConfig returns command checking consistency of go.mod files using provided config.
+func CheckDepsWithConfig(config DepsCheckConfig) types.CommandFunc {
+	return func(ctx context.Context, deps types.DepsFunc) error {
+		return checkDeps(ctx, deps, config)
+	}
+}
+
+func checkDeps(ctx context.Context, deps types.DepsFunc, config DepsCheckConfig) error {
+	deps(EnsureGo)
+
+	log := logger.Get(ctx)
+
+	goVersions := modVersions{}
+	requirements := map[string]modVersions{}
+	if err := helpers.OnModule("go.mod", func(path string) error {
+		modFile, err := readGoMod(ctx, path)
+		if err != nil {
+			return err
+		}
+
+		if modFile.Go != "" {
+			goVersions[modFile.Go] = append(goVersions[modFile.Go], path)
+		}
+		for _, req := range modFile.Require {
+			// Replaced modules are resolved differently, so versions are not comparable.
+			if lo.ContainsBy(modFile.Replace, func(r goModReplace) bool {
+				return r.Old.Path == req.Path
+			}) {
+				continue
+			}
+			if requirements[req.Path] == nil {
+				requirements[req.Path] = modVersions{}
+			}
+			requirements[req.Path][req.Version] = append(requirements[req.Path][req.Version], path)
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	report := &strings.Builder{}
+	updates := map[string][]string{}
+	if len(goVersions) > 1 {
+		highest := highestVersion(goVersions, compareGoVersions)
+		printModVersions(report, "go directive", goVersions, compareGoVersions)
+		for version, modules := range goVersions {
+			if compareGoVersions(version, highest) != 0 {
+				for _, module := range modules {
+					updates[module] = append(updates[module], "-go="+highest)
+				}
+			}
+		}
+	}
+	for _, dep := range sortedKeys(requirements) {
+		versions := requirements[dep]
+		if len(versions) < 2 {
+			continue
+		}
+		highest := highestVersion(versions, compareSemver)
+		printModVersions(report, dep, versions, compareSemver)
+		for version, modules := range versions {
+			if compareSemver(version, highest) != 0 {
+				for _, module := range modules {
+					updates[module] = append(updates[module], "-require="+dep+"@"+highest)
+				}
+			}
+		}
+	}
+
+	if len(updates) == 0 {
+		log.Info("Dependencies are consistent across modules")
+		return nil
+	}
+
+	if _, err := os.Stdout.WriteString(report.String()); err != nil {
+		return errors.WithStack(err)
+	}
+	if !config.Align {
+		return errors.Errorf("dependencies are inconsistent across %d module(s)", len(updates))
+	}
+
+	for _, module := range sortedKeys(updates) {
+		log.Info("Aligning dependencies", zap.String("path", module), zap.Strings("changes", updates[module]))
+
+		cmd := exec.Command(tools.Bin(ctx, "bin/go", tools.PlatformLocal),
+			append([]string{"mod", "edit"}, updates[module]...)...)
+		cmd.Env = env(ctx)
+		cmd.Dir = module
+		if err := libexec.Exec(ctx, cmd); err != nil {
+			return errors.Wrapf(err, "updating go.mod failed in module '%s'", module)
+		}
+
+		cmd = exec.Command(tools.Bin(ctx, "bin/go", tools.PlatformLocal), "mod", "tidy")
+		cmd.Env = env(ctx)
+		cmd.Dir = module
+		if err := libexec.Exec(ctx, cmd); err != nil {
+			return errors.Wrapf(err, "'go mod tidy' failed in module '%s'", module)
+		}
+	}
+	return nil
+}
+
+func readGoMod(ctx context.Context, modulePath string) (goModFile, error) {
+	buf := &bytes.Buffer{}
+	cmd := exec.Command(tools.Bin(ctx, "bin/go", tools.PlatformLocal), "mod", "edit", "-json")
+	cmd.Env = env(ctx)
+	cmd.Dir = modulePath
+	cmd.Stdout = buf
+	if err := libexec.Exec(ctx, cmd); err != nil {
+		return goModFile{}, errors.Wrapf(err, "reading go.mod failed in module '%s'", modulePath)
+	}
+
+	var modFile goModFile
+	if err := json.Unmarshal(buf.Bytes(), &modFile); err != nil {
+		return goModFile{}, errors.Wrapf(err, "parsing go.mod failed in module '%s'", modulePath)
+	}
+	return modFile, nil
+}
+
+func printModVersions(report *strings.Builder, name string, versions modVersions, compare func(a, b string) int) {
+	keys := lo.Keys(versions)
+	sort.Slice(keys, func(i, j int) bool {
+		return compare(keys[i], keys[j]) > 0
+	})
+
+	fmt.Fprintf(report, "%s:\n", name)
+	for _, version := range keys {
+		modules := versions[version]
+		sort.Strings(modules)
+		fmt.Fprintf(report, "  %s\t%s\n", version, strings.Join(modules, ", "))
+	}
+}
+
+func highestVersion(versions modVersions, compare func(a, b string) int) string {
+	var highest string
+	for version := range versions {
+		if highest == "" || compare(version, highest) > 0 ||
+			(compare(version, highest) == 0 && version > highest) {
+			highest = version
+		}
+	}
+	return highest
+}
+
+// goVersion is the version used by go directive, parsed according to the rules of go toolchain.
+type goVersion struct {
+	Major string
+	Minor string
+	Patch string
+	Kind  string
+	Pre   string
+}
+
+// compareGoVersions compares versions used by go directive, e.g. 1.22, 1.22.3 or 1.23rc1, using the ordering
+// of go toolchain. Starting from go 1.21, language version precedes its release candidates, which precede
+// the first release, so 1.21 < 1.21rc1 < 1.21.0. For older versions missing patch is the same as 0.
+func compareGoVersions(a, b string) int {
+	va := parseGoVersion(a)
+	vb := parseGoVersion(b)
+	if c := compareNumbers(va.Major, vb.Major); c != 0 {
+		return c
+	}
+	if c := compareNumbers(va.Minor, vb.Minor); c != 0 {
+		return c
+	}
+	if c := compareNumbers(va.Patch, vb.Patch); c != 0 {
+		return c
+	}
+	if c := strings.Compare(va.Kind, vb.Kind); c != 0 {
+		return c
+	}
+	return compareNumbers(va.Pre, vb.Pre)
+}
+
+// parseGoVersion parses version used by go directive. Invalid version is returned as zero value,
+// so it precedes all the valid ones.
+func parseGoVersion(v string) goVersion {
+	var result goVersion
+	var ok bool
+	if result.Major, v, ok = cutNumber(v); !ok {
+		return goVersion{}
+	}
+	if v == "" {
+		result.Minor = "0"
+		result.Patch = "0"
+		return result
+	}
+	if v[0] != '.' {
+		return goVersion{}
+	}
+	if result.Minor, v, ok = cutNumber(v[1:]); !ok {
+		return goVersion{}
+	}
+	if v == "" {
+		if compareNumbers(result.Minor, "21") < 0 {
+			result.Patch = "0"
+		}
+		return result
+	}
+	if v[0] == '.' {
+		if result.Patch, v, ok = cutNumber(v[1:]); !ok || v != "" {
+			return goVersion{}
+		}
+		return result
+	}
+
+	i := strings.IndexFunc(v, func(r rune) bool {
+		return r < 'a' || r > 'z'
+	})
+	switch {
+	case i == 0:
+		return goVersion{}
+	case i < 0:
+		result.Kind = v
+		return result
+	}
+	result.Kind = v[:i]
+	if result.Pre, v, ok = cutNumber(v[i:]); !ok || v != "" {
+		return goVersion{}
+	}
+	return result
+}
+
+// cutNumber cuts decimal number without leading zeros from the beginning of the string.
+func cutNumber(v string) (string, string, bool) {
+	i := strings.IndexFunc(v, func(r rune) bool {
+		return r < '0' || r > '9'
+	})
+	if i < 0 {
+		i = len(v)
+	}
+	if i == 0 || (v[0] == '0' && i != 1) {
+		return "", "", false
+	}
+	return v[:i], v[i:], true
+}
+
+// compareSemver compares semantic versions as used by go modules, including pseudo-versions.
+func compareSemver(a, b string) int {
+	aCore, aPre := splitSemver(a)
+	bCore, bPre := splitSemver(b)
+	for i := range max(len(aCore), len(bCore)) {
+		if c := compareNumbers(versionPart(aCore, i), versionPart(bCore, i)); c != 0 {
+			return c
+		}
+	}
+
+	switch {
+	case aPre == bPre:
+		return 0
+	case aPre == "":
+		return 1
+	case bPre == "":
+		return -1
+	}
+
+	aIDs := strings.Split(aPre, ".")
+	bIDs := strings.Split(bPre, ".")
+	for i := range min(len(aIDs), len(bIDs)) {
+		_, aErr := strconv.ParseUint(aIDs[i], 10, 64)
+		_, bErr := strconv.ParseUint(bIDs[i], 10, 64)
+		var c int
+		switch {
+		case aErr == nil && bErr == nil:
+			c = compareNumbers(aIDs[i], bIDs[i])
+		case aErr == nil:
+			c = -1
+		case bErr == nil:
+			c = 1
+		default:
+			c = strings.Compare(aIDs[i], bIDs[i])
+		}
+		if c != 0 {
+			return c
+		}
+	}
+	switch {
+	case len(aIDs) < len(bIDs):
+		return -1
+	case len(aIDs) > len(bIDs):
+		return 1
+	default:
+		return 0
+	}
+}
+
+func splitSemver(v string) ([]string, string) {
+	v = strings.TrimPrefix(v, "v")
+	v, _, _ = strings.Cut(v, "+")
+	core, pre, _ := strings.Cut(v, "-")
+	return strings.Split(core, "."), pre
+}
+
+func versionPart(parts []string, i int) string {
+	if i < len(parts) {
+		return parts[i]
+	}
+	return "0"
+}
+
+// compareNumbers compares decimal numbers of any length without leading zeros.
+func compareNumbers(a, b string) int {
+	if len(a) != len(b) {
+		if len(a) < len(b) {
+			return -1
+		}
+		return 1
+	}
+	return strings.Compare(a, b)
+}
@@ -0,0 +1,104 @@
+package golang
+
+import "testing"
+
+func TestCompareSemver(t *testing.T) {
+	tests := []struct {
+		a    string
+		b    string
+		want int
+	}{
+		{a: "v1.2.3", b: "v1.2.3", want: 0},
+		{a: "v1.2.3", b: "v1.2.4", want: -1},
+		{a: "v1.10.0", b: "v1.9.0", want: 1},
+		{a: "v2.0.0", b: "v1.99.99", want: 1},
+		{a: "v1.2.3+incompatible", b: "v1.2.3", want: 0},
+		{a: "v1.2.3-rc.1", b: "v1.2.3", want: -1},
+		{a: "v1.2.3", b: "v1.2.3-rc.1", want: 1},
+		{a: "v1.2.3-rc.2", b: "v1.2.3-rc.10", want: -1},
+		{a: "v1.2.3-alpha", b: "v1.2.3-beta", want: -1},
+		{a: "v1.2.3-1", b: "v1.2.3-alpha", want: -1},
+		{a: "v1.2.3-alpha", b: "v1.2.3-alpha.1", want: -1},
+		{a: "v0.0.0-20240101000000-abcdef123456", b: "v0.0.0-20231231000000-123456abcdef", want: 1},
+		{a: "v1.2.4-0.20240101000000-abcdef123456", b: "v1.2.3", want: 1},
+		{a: "v1.2.4-0.20240101000000-abcdef123456", b: "v1.2.4", want: -1},
+	}
+
+	for _, tt := range tests {
+		if got := compareSemver(tt.a, tt.b); got != tt.want {
+			t.Errorf("compareSemver(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestCompareGoVersions(t *testing.T) {
+	tests := []struct {
+		a    string
+		b    string
+		want int
+	}{
+		{a: "1.22", b: "1.22", want: 0},
+		{a: "1.22", b: "1.22.0", want: -1},
+		{a: "1.22", b: "1.22.3", want: -1},
+		{a: "1.23", b: "1.22.10", want: 1},
+		{a: "1.23", b: "1.23rc1", want: -1},
+		{a: "1.23rc1", b: "1.23.0", want: -1},
+		{a: "1.23rc1", b: "1.23rc2", want: -1},
+		{a: "1.23rc2", b: "1.23rc10", want: -1},
+		{a: "1.23beta1", b: "1.23rc1", want: -1},
+		{a: "1.23rc1", b: "1.22.5", want: 1},
+		{a: "1.23.1", b: "1.23.0", want: 1},
+		{a: "1.20", b: "1.20.0", want: 0},
+		{a: "1.9", b: "1.10", want: -1},
+		{a: "1", b: "1.0.0", want: 0},
+		{a: "invalid", b: "1.21", want: -1},
+		{a: "1.21.0rc1", b: "1.21", want: -1},
+	}
+
+	for _, tt := range tests {
+		if got := compareGoVersions(tt.a, tt.b); got != tt.want {
+			t.Errorf("compareGoVersions(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestHighestVersion(t *testing.T) {
+	tests := []struct {
+		versions modVersions
+		compare  func(a, b string) int
+		want     string
+	}{
+		{versions: modVersions{}, compare: compareSemver, want: ""},
+		{
+			versions: modVersions{"v1.9.0": nil, "v1.10.0": nil, "v1.10.0-rc.1": nil},
+			compare:  compareSemver,
+			want:     "v1.10.0",
+		},
+		{
+			versions: modVersions{"v1.2.3": nil, "v1.2.3+incompatible": nil},
+			compare:  compareSemver,
+			want:     "v1.2.3+incompatible",
+		},
+		{
+			versions: modVersions{"1.22": nil, "1.22.0": nil, "1.21.5": nil},
+			compare:  compareGoVersions,
+			want:     "1.22.0",
+		},
+		{
+			versions: modVersions{"1.23": nil, "1.23rc1": nil, "1.22.5": nil},
+			compare:  compareGoVersions,
+			want:     "1.23rc1",
+		},
+		{
+			versions: modVersions{"1.20": nil, "1.20.0": nil},
+			compare:  compareGoVersions,
+			want:     "1.20.0",
+		},
+	}
+
+	for _, tt := range tests {
+		if got := highestVersion(tt.versions, tt.compare); got != tt.want {
+			t.Errorf("highestVersion(%v) = %q, want %q", tt.versions, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package golang
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+
+	"github.com/outofforest/build/v2/pkg/helpers"
+	"github.com/outofforest/logger"
+	"github.com/outofforest/parallel"
+)
+
+// moduleOutput buffers the output produced while processing the module.
+type moduleOutput struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+// Write writes data to the buffer.
+func (mo *moduleOutput) Write(p []byte) (int, error) {
+	mo.mu.Lock()
+	defer mo.mu.Unlock()
+
+	return mo.buf.Write(p)
+}
+
+// onModules executes fn concurrently for each module containing the file, at most workers modules at a time.
+// If workers is not positive, number of CPUs is used. Output of the module, including logs written using
+// the logger taken from the context passed to fn, is buffered and printed once fn returns, so outputs
+// of different modules are not interleaved. Processing continues if fn fails for some modules,
+// all the failures are reported together.
+func onModules(ctx context.Context, fileName string, workers int, fn func(ctx context.Context, path string,
+	output io.Writer) error,
+) error {
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+
+	paths := []string{}
+	if err := helpers.OnModule(fileName, func(path string) error {
+		paths = append(paths, path)
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	var mu sync.Mutex
+	failures := map[string]error{}
+	slots := make(chan struct{}, workers)
+	err := parallel.Run(ctx, func(ctx context.Context, spawn parallel.SpawnFn) error {
+		for _, path := range paths {
+			spawn("module:"+path, parallel.Continue, func(ctx context.Context) error {
+				select {
+				case <-ctx.Done():
+					return errors.WithStack(ctx.Err())
+				case slots <- struct{}{}:
+				}
+				defer func() {
+					<-slots
+				}()
+
+				output := &moduleOutput{}
+				err := fn(logger.WithLogger(ctx, moduleLogger(ctx, output)), path, output)
+
+				mu.Lock()
+				defer mu.Unlock()
+
+				if _, err := os.Stdout.Write(output.buf.Bytes()); err != nil {
+					return errors.WithStack(err)
+				}
+				if err != nil {
+					if ctx.Err() != nil {
+						return err
+					}
+					failures[path] = err
+				}
+				return nil
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if len(failures) == 0 {
+		return nil
+	}
+
+	failedPaths := make([]string, 0, len(failures))
+	for path := range failures {
+		failedPaths = append(failedPaths, path)
+	}
+	sort.Strings(failedPaths)
+
+	messages := make([]string, 0, len(failedPaths))
+	for _, path := range failedPaths {
+		messages = append(messages, failures[path].Error())
+	}
+	return errors.Errorf("processing failed in %d module(s):\n%s", len(failures), strings.Join(messages, "\n"))
+}
+
+// moduleLogger returns logger writing to the output of the module.
+func moduleLogger(ctx context.Context, output io.Writer) *zap.Logger {
+	return zap.New(zapcore.NewCore(
+		zapcore.NewConsoleEncoder(logger.EncoderConfig),
+		zapcore.AddSync(output),
+		logger.Get(ctx).Level(),
+	))
+}
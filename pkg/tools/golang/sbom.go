@@ -0,0 +1,62 @@
+package golang
+
+import (
+	"context"
+	"debug/buildinfo"
+
+	"github.com/pkg/errors"
+
+	"github.com/outofforest/tools/pkg/tools/sbom"
+)
+
+// storeSBOM stores SBOM documents of the binary, derived from build info embedded by go compiler.
+func storeSBOM(ctx context.Context, config BuildConfig) error {
+	info, err := buildinfo.ReadFile(config.BinOutputPath)
+	if err != nil {
+		return errors.Wrapf(err, "reading build info of '%s' failed", config.BinOutputPath)
+	}
+
+	version := info.Main.Version
+	if version == "" || version == "(devel)" {
+		// Binaries are built with -buildvcs=false, so version is taken from the embedded build info
+		// or from the repository.
+		version = config.LDVariables[buildInfoPackage+".version"]
+		if version == "" {
+			gitInfo, err := getGitInfo(ctx, config.PackagePath)
+			if err != nil {
+				return err
+			}
+			version = gitInfo.Version
+		}
+	}
+	artifact := sbom.Component{
+		Version: version,
+		PURL:    goPURL(info.Main.Path, version),
+	}
+
+	components := []sbom.Component{{
+		Name:    "stdlib",
+		Version: info.GoVersion,
+		PURL:    goPURL("stdlib", info.GoVersion),
+	}}
+	for _, dep := range info.Deps {
+		module := dep
+		if dep.Replace != nil {
+			module = dep.Replace
+		}
+		components = append(components, sbom.Component{
+			Name:    dep.Path,
+			Version: module.Version,
+			PURL:    goPURL(module.Path, module.Version),
+		})
+	}
+
+	return sbom.Store(config.BinOutputPath, artifact, components, config.SBOMFormats)
+}
+
+func goPURL(path, version string) string {
+	if path == "" || version == "" {
+		return ""
+	}
+	return "pkg:golang/" + path + "@" + version
+}
@@ -0,0 +1,208 @@
+package golang
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/outofforest/tools/pkg/tools/junit"
+)
+
+// testEvent is the event produced by `go test -json`.
+type testEvent struct {
+	Action  string
+	Package string
+	Test    string
+	Elapsed float64
+	Output  string
+}
+
+type testResult struct {
+	Name    string
+	Action  string
+	Elapsed float64
+	Output  strings.Builder
+}
+
+type packageResult struct {
+	Name    string
+	Action  string
+	Elapsed float64
+	Output  strings.Builder
+	Tests   []*testResult
+	tests   map[string]*testResult
+}
+
+// testResultsWriter consumes output of `go test -json`, prints human-readable output and collects test results.
+type testResultsWriter struct {
+	output   io.Writer
+	buf      []byte
+	packages []*packageResult
+	index    map[string]*packageResult
+}
+
+func newTestResultsWriter(output io.Writer) *testResultsWriter {
+	return &testResultsWriter{
+		output: output,
+		index:  map[string]*packageResult{},
+	}
+}
+
+// Write processes the json stream.
+func (w *testResultsWriter) Write(p []byte) (int, error) {
+	w.buf = append(w.buf, p...)
+	for {
+		pos := bytes.IndexByte(w.buf, '\n')
+		if pos < 0 {
+			return len(p), nil
+		}
+		line := w.buf[:pos+1]
+		w.buf = w.buf[pos+1:]
+		if err := w.processLine(line); err != nil {
+			return 0, err
+		}
+	}
+}
+
+// Flush processes remaining incomplete line.
+func (w *testResultsWriter) Flush() error {
+	if len(w.buf) == 0 {
+		return nil
+	}
+	line := w.buf
+	w.buf = nil
+	return w.processLine(line)
+}
+
+func (w *testResultsWriter) processLine(line []byte) error {
+	var event testEvent
+	if err := json.Unmarshal(line, &event); err != nil || event.Action == "" {
+		// Not an event, pass it through.
+		_, err := w.output.Write(line)
+		return err
+	}
+
+	if event.Output != "" {
+		if _, err := io.WriteString(w.output, event.Output); err != nil {
+			return err
+		}
+	}
+
+	if event.Package == "" {
+		return nil
+	}
+
+	pkg := w.index[event.Package]
+	if pkg == nil {
+		pkg = &packageResult{
+			Name:  event.Package,
+			tests: map[string]*testResult{},
+		}
+		w.index[event.Package] = pkg
+		w.packages = append(w.packages, pkg)
+	}
+
+	if event.Test == "" {
+		switch event.Action {
+		case "output":
+			pkg.Output.WriteString(event.Output)
+		case "pass", "fail", "skip":
+			pkg.Action = event.Action
+			pkg.Elapsed = event.Elapsed
+		}
+		return nil
+	}
+
+	test := pkg.tests[event.Test]
+	if test == nil {
+		test = &testResult{Name: event.Test}
+		pkg.tests[event.Test] = test
+		pkg.Tests = append(pkg.Tests, test)
+	}
+	switch event.Action {
+	case "output":
+		test.Output.WriteString(event.Output)
+	case "pass", "fail", "skip":
+		test.Action = event.Action
+		test.Elapsed = event.Elapsed
+	}
+	return nil
+}
+
+// Report returns collected results in JUnit format.
+func (w *testResultsWriter) Report(name string) junit.TestSuites {
+	report := junit.TestSuites{Name: name}
+	var total float64
+	for _, pkg := range w.packages {
+		suite := junit.TestSuite{
+			Name: pkg.Name,
+			Time: junit.Seconds(seconds(pkg.Elapsed)),
+		}
+		total += pkg.Elapsed
+
+		testFailed := false
+		for _, test := range pkg.Tests {
+			tc := junit.TestCase{
+				Name:      test.Name,
+				ClassName: pkg.Name,
+				Time:      junit.Seconds(seconds(test.Elapsed)),
+			}
+			switch test.Action {
+			case "fail":
+				testFailed = true
+				tc.Failure = &junit.Failure{
+					Message: failureMessage(test.Output.String()),
+					Output:  test.Output.String(),
+				}
+			case "skip":
+				tc.Skipped = &junit.Skipped{Message: failureMessage(test.Output.String())}
+			case "":
+				// Test has been interrupted, e.g. by panic in another test or timeout.
+				tc.Failure = &junit.Failure{
+					Message: "test did not complete",
+					Output:  test.Output.String(),
+				}
+			default:
+				tc.SystemOut = test.Output.String()
+			}
+			suite.TestCases = append(suite.TestCases, tc)
+		}
+
+		// Package might fail without any failing test, e.g. due to compilation error or panic in TestMain.
+		if pkg.Action == "fail" && !testFailed {
+			suite.TestCases = append(suite.TestCases, junit.TestCase{
+				Name:      "(package)",
+				ClassName: pkg.Name,
+				Time:      junit.Seconds(seconds(pkg.Elapsed)),
+				Failure: &junit.Failure{
+					Message: failureMessage(pkg.Output.String()),
+					Output:  pkg.Output.String(),
+				},
+			})
+		}
+
+		report.Suites = append(report.Suites, suite)
+	}
+	report.Time = junit.Seconds(seconds(total))
+	return report
+}
+
+// failureMessage returns the last meaningful line of the output, which is usually the reason of the failure.
+func failureMessage(output string) string {
+	lines := strings.Split(output, "\n")
+	for i := len(lines) - 1; i >= 0; i-- {
+		line := strings.TrimSpace(lines[i])
+		if line == "" || line == "FAIL" || strings.HasPrefix(line, "=== ") || strings.HasPrefix(line, "--- ") ||
+			strings.HasPrefix(line, "FAIL\t") {
+			continue
+		}
+		return line
+	}
+	return ""
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
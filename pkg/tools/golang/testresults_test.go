@@ -0,0 +1,159 @@
+package golang
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/outofforest/tools/pkg/tools/junit"
+)
+
+func TestTestResultsWriter(t *testing.T) {
+	tests := []struct {
+		name   string
+		events []string
+		output string
+		report junit.TestSuites
+	}{
+		{
+			name: "passed and skipped tests",
+			events: []string{
+				`{"Action":"run","Package":"example.com/a","Test":"TestA"}`,
+				`{"Action":"output","Package":"example.com/a","Test":"TestA","Output":"=== RUN   TestA\n"}`,
+				`{"Action":"pass","Package":"example.com/a","Test":"TestA","Elapsed":0.5}`,
+				`{"Action":"run","Package":"example.com/a","Test":"TestB"}`,
+				`{"Action":"output","Package":"example.com/a","Test":"TestB","Output":"    a_test.go:10: not now\n"}`,
+				`{"Action":"skip","Package":"example.com/a","Test":"TestB","Elapsed":0}`,
+				`{"Action":"pass","Package":"example.com/a","Elapsed":1.25}`,
+			},
+			output: "=== RUN   TestA\n    a_test.go:10: not now\n",
+			report: junit.TestSuites{
+				Name: "module",
+				Time: "1.250",
+				Suites: []junit.TestSuite{{
+					Name: "example.com/a",
+					Time: "1.250",
+					TestCases: []junit.TestCase{
+						{Name: "TestA", ClassName: "example.com/a", Time: "0.500", SystemOut: "=== RUN   TestA\n"},
+						{
+							Name:      "TestB",
+							ClassName: "example.com/a",
+							Time:      "0.000",
+							Skipped:   &junit.Skipped{Message: "a_test.go:10: not now"},
+						},
+					},
+				}},
+			},
+		},
+		{
+			name: "failed and interrupted tests",
+			events: []string{
+				`{"Action":"output","Package":"example.com/b","Test":"TestA","Output":"    b_test.go:5: boom\n"}`,
+				`{"Action":"output","Package":"example.com/b","Test":"TestA","Output":"--- FAIL: TestA (0.10s)\n"}`,
+				`{"Action":"fail","Package":"example.com/b","Test":"TestA","Elapsed":0.1}`,
+				`{"Action":"output","Package":"example.com/b","Test":"TestB","Output":"=== RUN   TestB\n"}`,
+				`{"Action":"fail","Package":"example.com/b","Elapsed":2}`,
+			},
+			output: "    b_test.go:5: boom\n--- FAIL: TestA (0.10s)\n=== RUN   TestB\n",
+			report: junit.TestSuites{
+				Name: "module",
+				Time: "2.000",
+				Suites: []junit.TestSuite{{
+					Name: "example.com/b",
+					Time: "2.000",
+					TestCases: []junit.TestCase{
+						{
+							Name:      "TestA",
+							ClassName: "example.com/b",
+							Time:      "0.100",
+							Failure: &junit.Failure{
+								Message: "b_test.go:5: boom",
+								Output:  "    b_test.go:5: boom\n--- FAIL: TestA (0.10s)\n",
+							},
+						},
+						{
+							Name:      "TestB",
+							ClassName: "example.com/b",
+							Time:      "0.000",
+							Failure: &junit.Failure{
+								Message: "test did not complete",
+								Output:  "=== RUN   TestB\n",
+							},
+						},
+					},
+				}},
+			},
+		},
+		{
+			name: "package failed without failing test",
+			events: []string{
+				`# example.com/c`,
+				`{"Action":"output","Package":"example.com/c","Output":"FAIL\texample.com/c [build failed]\n"}`,
+				`{"Action":"fail","Package":"example.com/c","Elapsed":0}`,
+			},
+			output: "# example.com/c\nFAIL\texample.com/c [build failed]\n",
+			report: junit.TestSuites{
+				Name: "module",
+				Time: "0.000",
+				Suites: []junit.TestSuite{{
+					Name: "example.com/c",
+					Time: "0.000",
+					TestCases: []junit.TestCase{{
+						Name:      "(package)",
+						ClassName: "example.com/c",
+						Time:      "0.000",
+						Failure: &junit.Failure{
+							Message: "",
+							Output:  "FAIL\texample.com/c [build failed]\n",
+						},
+					}},
+				}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output := &bytes.Buffer{}
+			w := newTestResultsWriter(output)
+
+			// Stream is written in small chunks to verify that lines split between writes are handled.
+			stream := []byte(strings.Join(tt.events, "\n"))
+			for len(stream) > 0 {
+				n := min(len(stream), 7)
+				if _, err := w.Write(stream[:n]); err != nil {
+					t.Fatal(err)
+				}
+				stream = stream[n:]
+			}
+			if err := w.Flush(); err != nil {
+				t.Fatal(err)
+			}
+
+			if output.String() != tt.output {
+				t.Errorf("output: got %q, want %q", output.String(), tt.output)
+			}
+			if report := w.Report("module"); !reflect.DeepEqual(report, tt.report) {
+				t.Errorf("report: got %+v, want %+v", report, tt.report)
+			}
+		})
+	}
+}
+
+func TestFailureMessage(t *testing.T) {
+	tests := []struct {
+		output string
+		want   string
+	}{
+		{output: "", want: ""},
+		{output: "=== RUN   TestA\n    a_test.go:3: wrong value\n--- FAIL: TestA (0.00s)\n", want: "a_test.go:3: wrong value"},
+		{output: "panic: boom\n\nFAIL\nFAIL\texample.com/a\t0.01s\n", want: "panic: boom"},
+	}
+
+	for _, tt := range tests {
+		if got := failureMessage(tt.output); got != tt.want {
+			t.Errorf("failureMessage(%q) = %q, want %q", tt.output, got, tt.want)
+		}
+	}
+}
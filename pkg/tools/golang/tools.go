@@ -17,9 +17,10 @@ import (
 
 // Tool names.
 const (
-	Go        tools.Name = "go"
-	GolangCI  tools.Name = "golangci"
-	LibEVMOne tools.Name = "libevmone"
+	Go          tools.Name = "go"
+	GolangCI    tools.Name = "golangci"
+	GoVulnCheck tools.Name = "govulncheck"
+	LibEVMOne   tools.Name = "libevmone"
 )
 
 var t = []tools.Tool{
@@ -84,6 +85,13 @@ var t = []tools.Tool{
 		},
 	},
 
+	// https://pkg.go.dev/golang.org/x/vuln/cmd/govulncheck
+	GoPackageTool{
+		Name:    GoVulnCheck,
+		Version: "v1.1.3",
+		Package: "golang.org/x/vuln/cmd/govulncheck",
+	},
+
 	// https://github.com/ethereum/evmone/releases
 	tools.BinaryTool{
 		Name:    LibEVMOne,
@@ -204,6 +212,11 @@ func EnsureGolangCI(ctx context.Context, _ types.DepsFunc) error {
 	return tools.Ensure(ctx, GolangCI, tools.PlatformLocal)
 }
 
+// EnsureGoVulnCheck ensures that govulncheck is available.
+func EnsureGoVulnCheck(ctx context.Context, _ types.DepsFunc) error {
+	return tools.Ensure(ctx, GoVulnCheck, tools.PlatformLocal)
+}
+
 // EnsureLibEVMOne ensures that libevmone is available.
 func EnsureLibEVMOne(ctx context.Context, _ types.DepsFunc) error {
 	return tools.Ensure(ctx, LibEVMOne, tools.PlatformDockerAMD64)
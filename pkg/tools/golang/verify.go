@@ -0,0 +1,217 @@
+package golang
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/samber/lo"
+	"go.uber.org/zap"
+
+	"github.com/outofforest/build/v2/pkg/helpers"
+	"github.com/outofforest/build/v2/pkg/tools"
+	"github.com/outofforest/build/v2/pkg/types"
+	"github.com/outofforest/libexec"
+	"github.com/outofforest/logger"
+)
+
+// VerifyBuild builds the binary twice, each time using clean build cache, and verifies that both
+// binaries are identical. Verified binary is stored under BinOutputPath.
+func VerifyBuild(ctx context.Context, deps types.DepsFunc, config BuildConfig) error {
+	deps(EnsureGo)
+
+	// Build info is computed once, otherwise output of the first build, stored in the repository,
+	// would mark the second one as dirty.
+	if config.EmbedBuildInfo {
+		var err error
+		config, err = embedBuildInfo(ctx, config)
+		if err != nil {
+			return err
+		}
+		config.EmbedBuildInfo = false
+	}
+
+	binOutputPath := lo.Must(filepath.Abs(config.BinOutputPath))
+	envDir := tools.EnvDir(ctx)
+	if err := os.MkdirAll(envDir, 0o755); err != nil {
+		return errors.WithStack(err)
+	}
+
+	outputs := []string{binOutputPath + ".build1", binOutputPath + ".build2"}
+	cacheDirs := make([]string, 0, len(outputs))
+	defer func() {
+		for _, output := range outputs {
+			_ = os.Remove(output)
+		}
+		for _, cacheDir := range cacheDirs {
+			_ = os.RemoveAll(cacheDir)
+		}
+	}()
+
+	checksums := make([]string, 0, len(outputs))
+	for _, output := range outputs {
+		// Cache must be stored inside env dir because it is mounted when building in docker.
+		cacheDir, err := os.MkdirTemp(envDir, "go-cache-")
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		cacheDirs = append(cacheDirs, cacheDir)
+
+		buildConfig := config
+		buildConfig.BinOutputPath = output
+		buildConfig.SBOMFormats = nil
+		if err := build(ctx, deps, buildConfig, cacheDir); err != nil {
+			return err
+		}
+
+		checksum, err := sha256Checksum(output)
+		if err != nil {
+			return err
+		}
+		checksums = append(checksums, checksum)
+	}
+
+	if checksums[0] != checksums[1] {
+		diff, err := buildInfoDiff(ctx, outputs[0], outputs[1])
+		if err != nil {
+			return err
+		}
+		return errors.Errorf("build of package '%s' is not reproducible, checksums: %s != %s, build info diff:\n%s",
+			config.PackagePath, checksums[0], checksums[1], diff)
+	}
+
+	logger.Get(ctx).Info("Build is reproducible",
+		zap.String("package", config.PackagePath),
+		zap.String("sha256", checksums[0]))
+
+	if err := os.Rename(outputs[0], binOutputPath); err != nil {
+		return errors.WithStack(err)
+	}
+	if len(config.SBOMFormats) == 0 {
+		return nil
+	}
+	config.BinOutputPath = binOutputPath
+	return storeSBOM(ctx, config)
+}
+
+// VerifyBuildWithConfig returns command verifying that the build of the binary is reproducible.
+func VerifyBuildWithConfig(config BuildConfig) types.CommandFunc {
+	return func(ctx context.Context, deps types.DepsFunc) error {
+		return VerifyBuild(ctx, deps, config)
+	}
+}
+
+// VerifyBuilds verifies that builds of all the main packages found in go modules are reproducible.
+// Binaries are built for the local platform, with build info embedded, and discarded afterwards.
+func VerifyBuilds(ctx context.Context, deps types.DepsFunc) error {
+	deps(EnsureGo)
+
+	envDir := tools.EnvDir(ctx)
+	if err := os.MkdirAll(envDir, 0o755); err != nil {
+		return errors.WithStack(err)
+	}
+	outputDir, err := os.MkdirTemp(envDir, "verify-")
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer os.RemoveAll(outputDir)
+
+	// Each package is built twice using clean cache, so modules are processed sequentially.
+	return helpers.OnModule("go.mod", func(path string) error {
+		packages, err := mainPackages(ctx, path)
+		if err != nil {
+			return err
+		}
+		if len(packages) == 0 {
+			logger.Get(ctx).Info("No main packages", zap.String("path", path))
+			return nil
+		}
+
+		for _, pkg := range packages {
+			if err := VerifyBuild(ctx, deps, BuildConfig{
+				Platform:       tools.PlatformLocal,
+				PackagePath:    pkg,
+				BinOutputPath:  filepath.Join(outputDir, filepath.Base(pkg)),
+				EmbedBuildInfo: true,
+			}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// mainPackages returns directories of main packages found in the module.
+func mainPackages(ctx context.Context, modulePath string) ([]string, error) {
+	buf := &bytes.Buffer{}
+	cmd := exec.Command(tools.Bin(ctx, "bin/go", tools.PlatformLocal), "list", "-f",
+		`{{if eq .Name "main"}}{{.Dir}}{{end}}`, "./...")
+	cmd.Env = env(ctx)
+	cmd.Dir = modulePath
+	cmd.Stdout = buf
+	if err := libexec.Exec(ctx, cmd); err != nil {
+		return nil, errors.Wrapf(err, "listing packages failed in module '%s'", modulePath)
+	}
+	return strings.Fields(buf.String()), nil
+}
+
+func sha256Checksum(file string) (string, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	defer f.Close()
+
+	hasher := sha256.New()
+	if _, err := io.Copy(hasher, f); err != nil {
+		return "", errors.WithStack(err)
+	}
+	return hex.EncodeToString(hasher.Sum(nil)), nil
+}
+
+func buildInfoDiff(ctx context.Context, file1, file2 string) (string, error) {
+	info1, err := buildInfo(ctx, file1)
+	if err != nil {
+		return "", err
+	}
+	info2, err := buildInfo(ctx, file2)
+	if err != nil {
+		return "", err
+	}
+
+	diff := &strings.Builder{}
+	for _, line := range lo.Without(info1, info2...) {
+		fmt.Fprintf(diff, "- %s\n", line)
+	}
+	for _, line := range lo.Without(info2, info1...) {
+		fmt.Fprintf(diff, "+ %s\n", line)
+	}
+	if diff.Len() == 0 {
+		return "build info is identical, binaries differ in code\n", nil
+	}
+	return diff.String(), nil
+}
+
+func buildInfo(ctx context.Context, file string) ([]string, error) {
+	buf := &bytes.Buffer{}
+	cmd := exec.Command(tools.Bin(ctx, "bin/go", tools.PlatformLocal), "version", "-m", file)
+	cmd.Stdout = buf
+	if err := libexec.Exec(ctx, cmd); err != nil {
+		return nil, errors.Wrapf(err, "reading build info of '%s' failed", file)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	// First line contains the name of the file.
+	for i, line := range lines[1:] {
+		lines[i+1] = strings.TrimSpace(line)
+	}
+	return lines[1:], nil
+}
@@ -0,0 +1,500 @@
+package golang
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/samber/lo"
+	"go.uber.org/zap"
+
+	"github.com/outofforest/build/v2/pkg/tools"
+	"github.com/outofforest/build/v2/pkg/types"
+	"github.com/outofforest/libexec"
+	"github.com/outofforest/logger"
+)
+
+// VulnDBEnv is the name of environment variable containing path to the directory with offline
+// vulnerability database.
+const VulnDBEnv = "GO_VULN_DB"
+
+// AdvisoryDBEnv is the name of environment variable containing path to the local clone of GitHub advisory
+// database.
+const AdvisoryDBEnv = "GO_ADVISORY_DB"
+
+// osvAPIURL is the URL of OSV API used to fetch GitHub advisories.
+const osvAPIURL = "https://api.osv.dev/v1/vulns/"
+
+// VulnSeverity is the severity of vulnerability.
+type VulnSeverity int
+
+// Severities of vulnerabilities.
+const (
+	VulnSeverityUnknown VulnSeverity = iota
+	VulnSeverityLow
+	VulnSeverityMedium
+	VulnSeverityHigh
+	VulnSeverityCritical
+)
+
+func (s VulnSeverity) String() string {
+	switch s {
+	case VulnSeverityLow:
+		return "low"
+	case VulnSeverityMedium:
+		return "medium"
+	case VulnSeverityHigh:
+		return "high"
+	case VulnSeverityCritical:
+		return "critical"
+	default:
+		return "unknown"
+	}
+}
+
+// VulnConfig is the configuration for scanning dependencies for known vulnerabilities.
+type VulnConfig struct {
+	// DBPath is the path to the directory containing vulnerability database in the format served by
+	// https://vuln.go.dev. If empty, online database is used.
+	DBPath string
+
+	// AdvisoryDBPath is the path to the local clone of https://github.com/github/advisory-database.
+	// Go vulnerability database doesn't grade vulnerabilities, so their severity is taken from GitHub advisories
+	// they are aliased with. If empty, advisories are fetched from https://osv.dev unless DBPath is set.
+	AdvisoryDBPath string
+
+	// MinSeverity is the minimal severity of reachable vulnerability causing failure.
+	MinSeverity VulnSeverity
+
+	// UnknownSeverity is the severity assumed for vulnerabilities which can't be graded, e.g. when offline
+	// vulnerability database is used without advisory database. If not set, vulnerabilities of unknown severity
+	// always cause failure.
+	UnknownSeverity VulnSeverity
+
+	// Tags is go build tags used to analyze the code.
+	Tags []string
+
+	// Workers is the maximum number of modules scanned concurrently. If zero, number of CPUs is used.
+	Workers int
+}
+
+// DefaultVulnConfig returns default configuration for scanning vulnerabilities.
+// Paths to the offline databases are taken from GO_VULN_DB and GO_ADVISORY_DB environment variables.
+func DefaultVulnConfig() VulnConfig {
+	return VulnConfig{
+		DBPath:         os.Getenv(VulnDBEnv),
+		AdvisoryDBPath: os.Getenv(AdvisoryDBEnv),
+		MinSeverity:    VulnSeverityLow,
+	}
+}
+
+type vulnMessage struct {
+	OSV     *vulnEntry   `json:"osv"`
+	Finding *vulnFinding `json:"finding"`
+}
+
+type vulnEntry struct {
+	ID       string   `json:"id"`
+	Summary  string   `json:"summary"`
+	Aliases  []string `json:"aliases"`
+	Severity []struct {
+		Type  string `json:"type"`
+		Score string `json:"score"`
+	} `json:"severity"`
+	DatabaseSpecific struct {
+		Severity string `json:"severity"`
+	} `json:"database_specific"`
+}
+
+type vulnFinding struct {
+	OSV          string      `json:"osv"`
+	FixedVersion string      `json:"fixed_version"`
+	Trace        []vulnFrame `json:"trace"`
+}
+
+type vulnFrame struct {
+	Module   string `json:"module"`
+	Version  string `json:"version"`
+	Package  string `json:"package"`
+	Function string `json:"function"`
+	Receiver string `json:"receiver"`
+}
+
+// Vuln scans go modules for known vulnerabilities in dependencies.
+func Vuln(ctx context.Context, deps types.DepsFunc) error {
+	return vuln(ctx, deps, DefaultVulnConfig())
+}
+
+// VulnWithConfig returns command scanning go modules for known vulnerabilities using provided config.
+func VulnWithConfig(config VulnConfig) types.CommandFunc {
+	return func(ctx context.Context, deps types.DepsFunc) error {
+		return vuln(ctx, deps, config)
+	}
+}
+
+func vuln(ctx context.Context, deps types.DepsFunc, config VulnConfig) error {
+	deps(EnsureGo, EnsureGoVulnCheck)
+
+	args := []string{"-format", "json"}
+	if config.DBPath != "" {
+		dbPath, err := filepath.Abs(config.DBPath)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		if _, err := os.Stat(filepath.Join(dbPath, "index", "db.json")); err != nil {
+			return errors.Wrapf(err, "directory '%s' does not contain vulnerability database", dbPath)
+		}
+		args = append(args, "-db", "file://"+filepath.ToSlash(dbPath))
+	}
+	if len(config.Tags) > 0 {
+		args = append(args, "-tags", strings.Join(config.Tags, ","))
+	}
+	args = append(args, "./...")
+
+	advisories := newVulnAdvisories(config)
+	return onModules(ctx, "go.mod", config.Workers, func(ctx context.Context, path string, output io.Writer) error {
+		goCodePresent, err := containsGoCode(path)
+		if err != nil {
+			return err
+		}
+		if !goCodePresent {
+			return nil
+		}
+
+		logger.Get(ctx).Info("Scanning for vulnerabilities", zap.String("path", path))
+
+		buf := &bytes.Buffer{}
+		cmd := exec.Command(tools.Bin(ctx, "bin/govulncheck", tools.PlatformLocal), args...)
+		cmd.Env = env(ctx)
+		cmd.Dir = path
+		cmd.Stdout = buf
+		cmd.Stderr = output
+		if err := libexec.Exec(ctx, cmd); err != nil {
+			return errors.Wrapf(err, "scanning vulnerabilities failed in module '%s'", path)
+		}
+
+		entries, findings, err := parseVulnReport(buf)
+		if err != nil {
+			return errors.Wrapf(err, "parsing vulnerability report failed in module '%s'", path)
+		}
+
+		failing, err := reportVulns(output, entries, findings, config.MinSeverity, config.UnknownSeverity,
+			func(entry vulnEntry) (VulnSeverity, error) {
+				return gradeVuln(ctx, advisories, entry)
+			})
+		if err != nil {
+			return err
+		}
+		if failing > 0 {
+			return errors.Errorf("%d reachable vulnerability(ies) found in module '%s'", failing, path)
+		}
+		return nil
+	})
+}
+
+func parseVulnReport(r io.Reader) (map[string]vulnEntry, map[string][]vulnFinding, error) {
+	entries := map[string]vulnEntry{}
+	findings := map[string][]vulnFinding{}
+
+	decoder := json.NewDecoder(r)
+	for {
+		var msg vulnMessage
+		if err := decoder.Decode(&msg); err != nil {
+			if errors.Is(err, io.EOF) {
+				return entries, findings, nil
+			}
+			return nil, nil, errors.WithStack(err)
+		}
+		if msg.OSV != nil {
+			entries[msg.OSV.ID] = *msg.OSV
+		}
+		if msg.Finding != nil {
+			findings[msg.Finding.OSV] = append(findings[msg.Finding.OSV], *msg.Finding)
+		}
+	}
+}
+
+// reportVulns prints reachable vulnerabilities and returns the number of those having severity
+// not lower than the minimal one. Vulnerabilities which can't be graded are assumed to have unknownSeverity,
+// if it is not set they are always counted.
+func reportVulns(
+	output io.Writer,
+	entries map[string]vulnEntry,
+	findings map[string][]vulnFinding,
+	minSeverity VulnSeverity,
+	unknownSeverity VulnSeverity,
+	grade func(entry vulnEntry) (VulnSeverity, error),
+) (int, error) {
+	var failing, unreachable int
+	for _, id := range sortedKeys(findings) {
+		reachable := lo.Filter(findings[id], func(f vulnFinding, _ int) bool {
+			return len(f.Trace) > 0 && f.Trace[0].Function != ""
+		})
+		if len(reachable) == 0 {
+			unreachable++
+			continue
+		}
+
+		entry := entries[id]
+		severity, err := grade(entry)
+		if err != nil {
+			return 0, err
+		}
+		effectiveSeverity := severity
+		if effectiveSeverity == VulnSeverityUnknown {
+			effectiveSeverity = unknownSeverity
+		}
+		status := "ignored"
+		if effectiveSeverity == VulnSeverityUnknown || effectiveSeverity >= minSeverity {
+			status = "FAILED"
+			failing++
+		}
+
+		frame := reachable[0].Trace[0]
+		fixedVersion := reachable[0].FixedVersion
+		if fixedVersion == "" {
+			fixedVersion = "none"
+		}
+		if len(entry.Aliases) > 0 {
+			id += " (" + strings.Join(entry.Aliases, ", ") + ")"
+		}
+		if _, err := fmt.Fprintf(output, "%s [%s, %s] %s\n  module: %s@%s, fixed in: %s\n  reached: %s\n",
+			id, severity, status, entry.Summary, frame.Module, frame.Version, fixedVersion,
+			vulnSymbol(frame)); err != nil {
+			return 0, errors.WithStack(err)
+		}
+	}
+	if unreachable > 0 {
+		if _, err := fmt.Fprintf(output, "%d vulnerability(ies) found in dependencies but code does not call them\n",
+			unreachable); err != nil {
+			return 0, errors.WithStack(err)
+		}
+	}
+	return failing, nil
+}
+
+func vulnSymbol(frame vulnFrame) string {
+	if frame.Receiver != "" {
+		return fmt.Sprintf("%s.%s.%s", frame.Package, strings.TrimPrefix(frame.Receiver, "*"), frame.Function)
+	}
+	return fmt.Sprintf("%s.%s", frame.Package, frame.Function)
+}
+
+// gradeVuln returns severity of the vulnerability. If the entry is not graded, severity is taken from
+// GitHub advisories the vulnerability is aliased with.
+func gradeVuln(ctx context.Context, advisories *vulnAdvisories, entry vulnEntry) (VulnSeverity, error) {
+	if severity := vulnSeverity(entry); severity != VulnSeverityUnknown {
+		return severity, nil
+	}
+	for _, alias := range entry.Aliases {
+		if !strings.HasPrefix(alias, "GHSA-") {
+			continue
+		}
+		advisory, exists, err := advisories.Get(ctx, alias)
+		if err != nil {
+			return VulnSeverityUnknown, err
+		}
+		if !exists {
+			continue
+		}
+		if severity := vulnSeverity(advisory); severity != VulnSeverityUnknown {
+			return severity, nil
+		}
+	}
+	return VulnSeverityUnknown, nil
+}
+
+// vulnAdvisories caches GitHub advisories, so each of them is read once per run, even if it is reported
+// by many modules.
+type vulnAdvisories struct {
+	config VulnConfig
+
+	mu         sync.Mutex
+	advisories map[string]*vulnEntry
+}
+
+func newVulnAdvisories(config VulnConfig) *vulnAdvisories {
+	return &vulnAdvisories{
+		config:     config,
+		advisories: map[string]*vulnEntry{},
+	}
+}
+
+// Get returns GitHub advisory in OSV format.
+func (va *vulnAdvisories) Get(ctx context.Context, id string) (vulnEntry, bool, error) {
+	va.mu.Lock()
+	defer va.mu.Unlock()
+
+	if advisory, exists := va.advisories[id]; exists {
+		if advisory == nil {
+			return vulnEntry{}, false, nil
+		}
+		return *advisory, true, nil
+	}
+
+	advisory, exists, err := vulnAdvisory(ctx, va.config, id)
+	if err != nil {
+		return vulnEntry{}, false, err
+	}
+	if exists {
+		va.advisories[id] = &advisory
+	} else {
+		va.advisories[id] = nil
+	}
+	return advisory, exists, nil
+}
+
+// vulnAdvisory returns GitHub advisory in OSV format. Advisory is read from the local advisory database
+// if configured, otherwise it is fetched from OSV API unless offline vulnerability database is used.
+func vulnAdvisory(ctx context.Context, config VulnConfig, id string) (vulnEntry, bool, error) {
+	var advisory vulnEntry
+	switch {
+	case config.AdvisoryDBPath != "":
+		// Advisories are stored as advisories/<kind>/<year>/<month>/<id>/<id>.json.
+		files, err := filepath.Glob(filepath.Join(config.AdvisoryDBPath, "advisories", "*", "*", "*", id,
+			id+".json"))
+		if err != nil {
+			return vulnEntry{}, false, errors.WithStack(err)
+		}
+		if len(files) == 0 {
+			return vulnEntry{}, false, nil
+		}
+		content, err := os.ReadFile(files[0])
+		if err != nil {
+			return vulnEntry{}, false, errors.WithStack(err)
+		}
+		if err := json.Unmarshal(content, &advisory); err != nil {
+			return vulnEntry{}, false, errors.Wrapf(err, "parsing advisory '%s' failed", files[0])
+		}
+		return advisory, true, nil
+	case config.DBPath != "":
+		return vulnEntry{}, false, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, osvAPIURL+id, nil)
+	if err != nil {
+		return vulnEntry{}, false, errors.WithStack(err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return vulnEntry{}, false, errors.Wrapf(err, "fetching advisory '%s' failed", id)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return vulnEntry{}, false, nil
+	default:
+		return vulnEntry{}, false, errors.Errorf("fetching advisory '%s' failed with status %s", id, resp.Status)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&advisory); err != nil {
+		return vulnEntry{}, false, errors.Wrapf(err, "parsing advisory '%s' failed", id)
+	}
+	return advisory, true, nil
+}
+
+// vulnSeverity returns severity of the vulnerability. Severity is taken from the database-specific
+// field if present, otherwise it is computed from CVSS v3 vector.
+func vulnSeverity(entry vulnEntry) VulnSeverity {
+	switch strings.ToLower(entry.DatabaseSpecific.Severity) {
+	case "low":
+		return VulnSeverityLow
+	case "moderate", "medium":
+		return VulnSeverityMedium
+	case "high":
+		return VulnSeverityHigh
+	case "critical":
+		return VulnSeverityCritical
+	}
+
+	for _, s := range entry.Severity {
+		if s.Type != "CVSS_V3" {
+			continue
+		}
+		score, ok := cvss3Score(s.Score)
+		if !ok {
+			continue
+		}
+		switch {
+		case score >= 9:
+			return VulnSeverityCritical
+		case score >= 7:
+			return VulnSeverityHigh
+		case score >= 4:
+			return VulnSeverityMedium
+		default:
+			return VulnSeverityLow
+		}
+	}
+	return VulnSeverityUnknown
+}
+
+// cvss3Score computes base score of the CVSS v3 vector.
+func cvss3Score(vector string) (float64, bool) {
+	metrics := map[string]string{}
+	for _, part := range strings.Split(vector, "/")[1:] {
+		key, value, ok := strings.Cut(part, ":")
+		if !ok {
+			return 0, false
+		}
+		metrics[key] = value
+	}
+
+	scopeChanged := metrics["S"] == "C"
+	weights := map[string]map[string]float64{
+		"AV": {"N": 0.85, "A": 0.62, "L": 0.55, "P": 0.2},
+		"AC": {"L": 0.77, "H": 0.44},
+		"PR": {"N": 0.85, "L": 0.62, "H": 0.27},
+		"UI": {"N": 0.85, "R": 0.62},
+		"C":  {"H": 0.56, "L": 0.22, "N": 0},
+		"I":  {"H": 0.56, "L": 0.22, "N": 0},
+		"A":  {"H": 0.56, "L": 0.22, "N": 0},
+	}
+	if scopeChanged {
+		weights["PR"]["L"] = 0.68
+		weights["PR"]["H"] = 0.5
+	}
+
+	values := map[string]float64{}
+	for metric, w := range weights {
+		value, ok := w[metrics[metric]]
+		if !ok {
+			return 0, false
+		}
+		values[metric] = value
+	}
+
+	iss := 1 - (1-values["C"])*(1-values["I"])*(1-values["A"])
+	impact := 6.42 * iss
+	if scopeChanged {
+		impact = 7.52*(iss-0.029) - 3.25*math.Pow(iss-0.02, 15)
+	}
+	if impact <= 0 {
+		return 0, true
+	}
+	exploitability := 8.22 * values["AV"] * values["AC"] * values["PR"] * values["UI"]
+	if scopeChanged {
+		return cvssRoundUp(min(1.08*(impact+exploitability), 10)), true
+	}
+	return cvssRoundUp(min(impact+exploitability, 10)), true
+}
+
+// cvssRoundUp rounds the value up to one decimal place as defined by CVSS v3.1 specification.
+func cvssRoundUp(value float64) float64 {
+	i := int(math.Round(value * 100000))
+	if i%10000 == 0 {
+		return float64(i) / 100000
+	}
+	return (math.Floor(float64(i)/10000) + 1) / 10
+}
@@ -0,0 +1,223 @@
+package golang
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCVSS3Score(t *testing.T) {
+	tests := []struct {
+		vector string
+		score  float64
+		valid  bool
+	}{
+		{vector: "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H", score: 9.8, valid: true},
+		{vector: "CVSS:3.1/AV:N/AC:L/PR:L/UI:N/S:C/C:H/I:H/A:H", score: 9.9, valid: true},
+		{vector: "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:N/I:N/A:H", score: 7.5, valid: true},
+		{vector: "CVSS:3.1/AV:N/AC:L/PR:N/UI:R/S:C/C:L/I:L/A:N", score: 6.1, valid: true},
+		{vector: "CVSS:3.0/AV:N/AC:H/PR:N/UI:R/S:U/C:L/I:N/A:N", score: 3.1, valid: true},
+		{vector: "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:N/I:N/A:N", score: 0, valid: true},
+		{vector: "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H", valid: false},
+		{vector: "CVSS:3.1/AV:X/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H", valid: false},
+		{vector: "CVSS:3.1/AV", valid: false},
+	}
+
+	for _, tt := range tests {
+		score, valid := cvss3Score(tt.vector)
+		if valid != tt.valid || score != tt.score {
+			t.Errorf("cvss3Score(%q) = %.1f, %t, want %.1f, %t", tt.vector, score, valid, tt.score, tt.valid)
+		}
+	}
+}
+
+func TestVulnSeverity(t *testing.T) {
+	tests := []struct {
+		name     string
+		entry    string
+		severity VulnSeverity
+	}{
+		{
+			name:     "database specific",
+			entry:    `{"database_specific":{"severity":"MODERATE"}}`,
+			severity: VulnSeverityMedium,
+		},
+		{
+			name: "database specific preferred",
+			entry: `{"database_specific":{"severity":"LOW"},` +
+				`"severity":[{"type":"CVSS_V3","score":"CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H"}]}`,
+			severity: VulnSeverityLow,
+		},
+		{
+			name:     "critical score",
+			entry:    `{"severity":[{"type":"CVSS_V3","score":"CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H"}]}`,
+			severity: VulnSeverityCritical,
+		},
+		{
+			name:     "high score",
+			entry:    `{"severity":[{"type":"CVSS_V3","score":"CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:N/I:N/A:H"}]}`,
+			severity: VulnSeverityHigh,
+		},
+		{
+			name:     "medium score",
+			entry:    `{"severity":[{"type":"CVSS_V3","score":"CVSS:3.1/AV:N/AC:L/PR:N/UI:R/S:C/C:L/I:L/A:N"}]}`,
+			severity: VulnSeverityMedium,
+		},
+		{
+			name: "low score after unsupported ones",
+			entry: `{"severity":[{"type":"CVSS_V4","score":"CVSS:4.0/AV:N"},` +
+				`{"type":"CVSS_V3","score":"invalid"},` +
+				`{"type":"CVSS_V3","score":"CVSS:3.1/AV:N/AC:H/PR:N/UI:R/S:U/C:L/I:N/A:N"}]}`,
+			severity: VulnSeverityLow,
+		},
+		{
+			name:     "not graded",
+			entry:    `{"id":"GO-2024-0001"}`,
+			severity: VulnSeverityUnknown,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var entry vulnEntry
+			if err := json.Unmarshal([]byte(tt.entry), &entry); err != nil {
+				t.Fatal(err)
+			}
+			if severity := vulnSeverity(entry); severity != tt.severity {
+				t.Errorf("got %s, want %s", severity, tt.severity)
+			}
+		})
+	}
+}
+
+func TestGradeVuln(t *testing.T) {
+	advisoryDir := t.TempDir()
+	for id, advisory := range map[string]string{
+		"GHSA-aaaa-aaaa-aaaa": `{"id":"GHSA-aaaa-aaaa-aaaa","database_specific":{"severity":"HIGH"}}`,
+		"GHSA-bbbb-bbbb-bbbb": `{"id":"GHSA-bbbb-bbbb-bbbb"}`,
+	} {
+		dir := filepath.Join(advisoryDir, "advisories", "github-reviewed", "2024", "01", id)
+		if err := os.MkdirAll(dir, 0o700); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, id+".json"), []byte(advisory), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	advisories := newVulnAdvisories(VulnConfig{AdvisoryDBPath: advisoryDir})
+
+	tests := []struct {
+		name     string
+		entry    string
+		severity VulnSeverity
+	}{
+		{
+			name:     "graded entry",
+			entry:    `{"aliases":["GHSA-aaaa-aaaa-aaaa"],"database_specific":{"severity":"LOW"}}`,
+			severity: VulnSeverityLow,
+		},
+		{
+			name:     "graded advisory",
+			entry:    `{"aliases":["CVE-2024-0001","GHSA-bbbb-bbbb-bbbb","GHSA-aaaa-aaaa-aaaa"]}`,
+			severity: VulnSeverityHigh,
+		},
+		{
+			name:     "missing advisory",
+			entry:    `{"aliases":["GHSA-cccc-cccc-cccc"]}`,
+			severity: VulnSeverityUnknown,
+		},
+		{
+			name:     "no advisory",
+			entry:    `{"aliases":["CVE-2024-0001"]}`,
+			severity: VulnSeverityUnknown,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var entry vulnEntry
+			if err := json.Unmarshal([]byte(tt.entry), &entry); err != nil {
+				t.Fatal(err)
+			}
+			severity, err := gradeVuln(context.Background(), advisories, entry)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if severity != tt.severity {
+				t.Errorf("got %s, want %s", severity, tt.severity)
+			}
+		})
+	}
+}
+
+func TestReportVulns(t *testing.T) {
+	report := `{"config":{"scanner_name":"govulncheck"}}
+{"osv":{"id":"GO-2024-0001","summary":"First","aliases":["GHSA-aaaa-aaaa-aaaa"]}}
+{"osv":{"id":"GO-2024-0002","summary":"Second"}}
+{"osv":{"id":"GO-2024-0003","summary":"Third"}}
+{"osv":{"id":"GO-2024-0004","summary":"Fourth"}}
+{"finding":{"osv":"GO-2024-0001","fixed_version":"v1.2.0",` +
+		`"trace":[{"module":"example.com/a","version":"v1.1.0","package":"example.com/a/b","function":"F"}]}}
+{"finding":{"osv":"GO-2024-0002","trace":[{"module":"example.com/c","version":"v0.1.0",` +
+		`"package":"example.com/c","receiver":"*T","function":"M"}]}}
+{"finding":{"osv":"GO-2024-0003","trace":[{"module":"example.com/d","version":"v0.1.0",` +
+		`"package":"example.com/d"}]}}
+{"finding":{"osv":"GO-2024-0004","trace":[{"module":"example.com/e","version":"v0.1.0",` +
+		`"package":"example.com/e","function":"G"}]}}
+`
+	entries, findings, err := parseVulnReport(strings.NewReader(report))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	severities := map[string]VulnSeverity{
+		"GO-2024-0001": VulnSeverityHigh,
+		"GO-2024-0002": VulnSeverityLow,
+		"GO-2024-0004": VulnSeverityUnknown,
+	}
+	tests := []struct {
+		name            string
+		unknownSeverity VulnSeverity
+		failing         int
+		unknownStatus   string
+	}{
+		{name: "unknown fails", unknownSeverity: VulnSeverityUnknown, failing: 2, unknownStatus: "FAILED"},
+		{name: "unknown assumed low", unknownSeverity: VulnSeverityLow, failing: 1, unknownStatus: "ignored"},
+		{name: "unknown assumed high", unknownSeverity: VulnSeverityHigh, failing: 2, unknownStatus: "FAILED"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output := &bytes.Buffer{}
+			failing, err := reportVulns(output, entries, findings, VulnSeverityMedium, tt.unknownSeverity,
+				func(entry vulnEntry) (VulnSeverity, error) {
+					return severities[entry.ID], nil
+				})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if failing != tt.failing {
+				t.Errorf("failing: got %d, want %d", failing, tt.failing)
+			}
+
+			want := `GO-2024-0001 (GHSA-aaaa-aaaa-aaaa) [high, FAILED] First
+  module: example.com/a@v1.1.0, fixed in: v1.2.0
+  reached: example.com/a/b.F
+GO-2024-0002 [low, ignored] Second
+  module: example.com/c@v0.1.0, fixed in: none
+  reached: example.com/c.T.M
+GO-2024-0004 [unknown, ` + tt.unknownStatus + `] Fourth
+  module: example.com/e@v0.1.0, fixed in: none
+  reached: example.com/e.G
+1 vulnerability(ies) found in dependencies but code does not call them
+`
+			if output.String() != want {
+				t.Errorf("got:\n%s\nwant:\n%s", output.String(), want)
+			}
+		})
+	}
+}
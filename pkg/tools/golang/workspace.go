@@ -0,0 +1,116 @@
+package golang
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+	"github.com/samber/lo"
+	"go.uber.org/zap"
+
+	"github.com/outofforest/build/v2/pkg/helpers"
+	"github.com/outofforest/build/v2/pkg/tools"
+	"github.com/outofforest/build/v2/pkg/types"
+	"github.com/outofforest/libexec"
+	"github.com/outofforest/logger"
+)
+
+// WorkspaceFile is the name of go workspace file generated in the root of the repository.
+const WorkspaceFile = "go.work"
+
+// GenerateWorkspace generates go.work file covering all the go modules in the repository.
+// If the file already exists, modules which no longer exist are removed and new ones are added,
+// other content of the file is preserved.
+func GenerateWorkspace(ctx context.Context, deps types.DepsFunc) error {
+	deps(EnsureGo)
+
+	log := logger.Get(ctx)
+
+	modules := map[string]bool{}
+	if err := helpers.OnModule("go.mod", func(path string) error {
+		modules[filepath.Clean(path)] = true
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	workFile := lo.Must(filepath.Abs(WorkspaceFile))
+	envs := withEnv(env(ctx), "GOWORK="+workFile)
+
+	if _, err := os.Stat(workFile); err != nil {
+		if !os.IsNotExist(err) {
+			return errors.WithStack(err)
+		}
+
+		log.Info("Creating go workspace", zap.String("path", workFile))
+		cmd := exec.Command(tools.Bin(ctx, "bin/go", tools.PlatformLocal), "work", "init")
+		cmd.Env = envs
+		if err := libexec.Exec(ctx, cmd); err != nil {
+			return errors.Wrap(err, "creating go workspace failed")
+		}
+	}
+
+	buf := &bytes.Buffer{}
+	cmd := exec.Command(tools.Bin(ctx, "bin/go", tools.PlatformLocal), "work", "edit", "-json")
+	cmd.Env = envs
+	cmd.Stdout = buf
+	if err := libexec.Exec(ctx, cmd); err != nil {
+		return errors.Wrap(err, "reading go workspace failed")
+	}
+	var work struct {
+		Use []struct {
+			DiskPath string
+		}
+	}
+	if err := json.Unmarshal(buf.Bytes(), &work); err != nil {
+		return errors.Wrap(err, "parsing go workspace failed")
+	}
+
+	editArgs := []string{"work", "edit"}
+	for _, use := range work.Use {
+		path := filepath.Clean(use.DiskPath)
+		if modules[path] {
+			delete(modules, path)
+			continue
+		}
+		log.Info("Removing module from go workspace", zap.String("path", use.DiskPath))
+		editArgs = append(editArgs, "-dropuse="+use.DiskPath)
+	}
+	for _, path := range sortedKeys(modules) {
+		log.Info("Adding module to go workspace", zap.String("path", path))
+		editArgs = append(editArgs, "-use="+path)
+	}
+	if len(editArgs) == 2 {
+		log.Info("Go workspace is up to date", zap.String("path", workFile))
+		return nil
+	}
+
+	cmd = exec.Command(tools.Bin(ctx, "bin/go", tools.PlatformLocal), editArgs...)
+	cmd.Env = envs
+	if err := libexec.Exec(ctx, cmd); err != nil {
+		return errors.Wrap(err, "updating go workspace failed")
+	}
+	return nil
+}
+
+// workspaceEnv returns environment running go in workspace mode using go.work file of the repository.
+// If workspace mode is disabled, go.work files are ignored, so the one generated in the repository
+// doesn't affect commands running in module mode.
+func workspaceEnv(ctx context.Context, workspace bool) ([]string, error) {
+	if !workspace {
+		return withEnv(env(ctx), "GOWORK=off"), nil
+	}
+
+	workFile := lo.Must(filepath.Abs(WorkspaceFile))
+	if _, err := os.Stat(workFile); err != nil {
+		if os.IsNotExist(err) {
+			return nil, errors.Errorf("go workspace file '%s' does not exist, generate it first", workFile)
+		}
+		return nil, errors.WithStack(err)
+	}
+	return withEnv(env(ctx), "GOWORK="+workFile), nil
+}
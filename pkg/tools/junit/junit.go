@@ -0,0 +1,111 @@
+package junit
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// ReportDir is the directory where test reports are stored.
+const ReportDir = "test-results"
+
+// TestSuites is the root element of the report.
+type TestSuites struct {
+	XMLName  xml.Name    `xml:"testsuites" json:"-"`
+	Name     string      `xml:"name,attr" json:"name"`
+	Tests    int         `xml:"tests,attr" json:"tests"`
+	Failures int         `xml:"failures,attr" json:"failures"`
+	Skipped  int         `xml:"skipped,attr" json:"skipped"`
+	Time     string      `xml:"time,attr" json:"time"`
+	Suites   []TestSuite `xml:"testsuite" json:"suites"`
+}
+
+// TestSuite contains results of tests from single package.
+type TestSuite struct {
+	Name      string     `xml:"name,attr" json:"name"`
+	Tests     int        `xml:"tests,attr" json:"tests"`
+	Failures  int        `xml:"failures,attr" json:"failures"`
+	Skipped   int        `xml:"skipped,attr" json:"skipped"`
+	Time      string     `xml:"time,attr" json:"time"`
+	TestCases []TestCase `xml:"testcase" json:"testCases"`
+}
+
+// TestCase is the result of single test.
+type TestCase struct {
+	Name      string   `xml:"name,attr" json:"name"`
+	ClassName string   `xml:"classname,attr" json:"className"`
+	Time      string   `xml:"time,attr" json:"time"`
+	Failure   *Failure `xml:"failure,omitempty" json:"failure,omitempty"`
+	Skipped   *Skipped `xml:"skipped,omitempty" json:"skipped,omitempty"`
+	SystemOut string   `xml:"system-out,omitempty" json:"systemOut,omitempty"`
+}
+
+// Failure describes test failure.
+type Failure struct {
+	Message string `xml:"message,attr" json:"message"`
+	Output  string `xml:",chardata" json:"output"`
+}
+
+// Skipped marks skipped test.
+type Skipped struct {
+	Message string `xml:"message,attr" json:"message"`
+}
+
+// Seconds formats duration the way it is expected in the report.
+func Seconds(d time.Duration) string {
+	return strconv.FormatFloat(d.Seconds(), 'f', 3, 64)
+}
+
+// Summarize computes counters of test suites and the whole report.
+func Summarize(report TestSuites) TestSuites {
+	report.Tests = 0
+	report.Failures = 0
+	report.Skipped = 0
+	for i, suite := range report.Suites {
+		suite.Tests = len(suite.TestCases)
+		suite.Failures = 0
+		suite.Skipped = 0
+		for _, tc := range suite.TestCases {
+			if tc.Failure != nil {
+				suite.Failures++
+			}
+			if tc.Skipped != nil {
+				suite.Skipped++
+			}
+		}
+		report.Suites[i] = suite
+		report.Tests += suite.Tests
+		report.Failures += suite.Failures
+		report.Skipped += suite.Skipped
+	}
+	return report
+}
+
+// Store stores the report in JUnit XML and JSON formats.
+func Store(dir, name string, report TestSuites) error {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return errors.WithStack(err)
+	}
+
+	report = Summarize(report)
+
+	xmlReport, err := xml.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, name+".xml"), append([]byte(xml.Header), xmlReport...),
+		0o600); err != nil {
+		return errors.WithStack(err)
+	}
+
+	jsonReport, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	return errors.WithStack(os.WriteFile(filepath.Join(dir, name+".json"), jsonReport, 0o600))
+}
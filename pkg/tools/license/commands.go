@@ -0,0 +1,11 @@
+package license
+
+import "github.com/outofforest/build/v2/pkg/types"
+
+// Commands is a set of commands auditing licenses of dependencies.
+var Commands = map[string]types.Command{
+	"license/audit": {
+		Description: "Audits licenses of go and rust dependencies",
+		Fn:          Audit,
+	},
+}
@@ -0,0 +1,117 @@
+package license
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/samber/lo"
+)
+
+var (
+	licenseFileRegExp = regexp.MustCompile(`(?i)^(?:LICEN[CS]E|COPYING|UNLICENSE)(?:[-_.].*)?$`)
+	whitespaceRegExp  = regexp.MustCompile(`\s+`)
+)
+
+// headSize is the size of the beginning of the license text where the title is expected.
+const headSize = 200
+
+// licenseMatchers detect license by phrases found in the text of the license file. More specific licenses
+// go first. GNU licenses refer to each other, so they are recognized by the title only.
+var licenseMatchers = []struct {
+	ID      string
+	Head    bool
+	Phrases []string
+}{
+	{ID: "AGPL-3.0", Head: true, Phrases: []string{"gnu affero general public license", "version 3"}},
+	{ID: "LGPL-3.0", Head: true, Phrases: []string{"gnu lesser general public license", "version 3"}},
+	{ID: "LGPL-2.1", Head: true, Phrases: []string{"gnu lesser general public license", "version 2.1"}},
+	{ID: "GPL-3.0", Head: true, Phrases: []string{"gnu general public license", "version 3"}},
+	{ID: "GPL-2.0", Head: true, Phrases: []string{"gnu general public license", "version 2"}},
+	{ID: "MPL-2.0", Phrases: []string{"mozilla public license", "2.0"}},
+	{ID: "EPL-2.0", Phrases: []string{"eclipse public license - v 2.0"}},
+	{ID: "Apache-2.0", Phrases: []string{"apache license", "version 2.0"}},
+	{ID: "BSD-3-Clause", Phrases: []string{
+		"redistribution and use in source and binary forms", "neither the name",
+	}},
+	{ID: "BSD-3-Clause", Phrases: []string{
+		"redistribution and use in source and binary forms", "names of its contributors may not be used",
+	}},
+	{ID: "BSD-2-Clause", Phrases: []string{"redistribution and use in source and binary forms"}},
+	{ID: "MIT", Phrases: []string{"permission is hereby granted, free of charge"}},
+	{ID: "ISC", Phrases: []string{
+		"permission to use, copy, modify, and/or distribute this software for any purpose",
+		"provided that the above copyright notice",
+	}},
+	{ID: "0BSD", Phrases: []string{
+		"permission to use, copy, modify, and/or distribute this software for any purpose",
+	}},
+	{ID: "Unlicense", Phrases: []string{"this is free and unencumbered software released into the public domain"}},
+	{ID: "CC0-1.0", Phrases: []string{"cc0 1.0 universal"}},
+	{ID: "Zlib", Phrases: []string{
+		"this software is provided 'as-is', without any express or implied warranty",
+		"altered source versions must be plainly marked as such",
+	}},
+}
+
+// Detect detects license of the package stored in the directory. It returns SPDX license expression and
+// the names of license files found. If there are many license files, all the licenses must be respected.
+func Detect(dir string) (string, []string, error) {
+	if dir == "" {
+		return Unknown, nil, nil
+	}
+
+	dirEntries, err := os.ReadDir(dir)
+	if err != nil {
+		return "", nil, errors.WithStack(err)
+	}
+
+	var files []string
+	var ids []string
+	for _, dirEntry := range dirEntries {
+		if dirEntry.IsDir() || !licenseFileRegExp.MatchString(dirEntry.Name()) {
+			continue
+		}
+
+		content, err := os.ReadFile(filepath.Join(dir, dirEntry.Name()))
+		if err != nil {
+			return "", nil, errors.WithStack(err)
+		}
+
+		files = append(files, dirEntry.Name())
+		ids = append(ids, detectText(string(content)))
+	}
+
+	ids = lo.Uniq(ids)
+	switch {
+	case len(ids) == 0:
+		return Unknown, nil, nil
+	case lo.Contains(ids, Unknown):
+		return Unknown, files, nil
+	case len(ids) == 1:
+		return ids[0], files, nil
+	default:
+		sort.Strings(ids)
+		return strings.Join(ids, " AND "), files, nil
+	}
+}
+
+func detectText(text string) string {
+	text = strings.TrimSpace(whitespaceRegExp.ReplaceAllString(strings.ToLower(text), " "))
+	head := text[:min(len(text), headSize)]
+	for _, m := range licenseMatchers {
+		t := text
+		if m.Head {
+			t = head
+		}
+		if lo.EveryBy(m.Phrases, func(phrase string) bool {
+			return strings.Contains(t, phrase)
+		}) {
+			return m.ID
+		}
+	}
+	return Unknown
+}
@@ -0,0 +1,143 @@
+package license
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const (
+	mitText = `MIT License
+
+Copyright (c) 2024 Someone
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction.
+`
+	apacheText = `
+                                 Apache License
+                           Version 2.0, January 2004
+                        http://www.apache.org/licenses/
+`
+	gpl3Text = `                    GNU GENERAL PUBLIC LICENSE
+                       Version 3, 29 June 2007
+
+ Copyright (C) 2007 Free Software Foundation, Inc. <https://fsf.org/>
+`
+)
+
+func TestDetectText(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{name: "MIT", text: mitText, want: "MIT"},
+		{name: "Apache", text: apacheText, want: "Apache-2.0"},
+		{name: "GPL-3.0", text: gpl3Text, want: "GPL-3.0"},
+		{
+			name: "GPL-3.0 mentioning Affero",
+			text: gpl3Text + strings.Repeat("text ", headSize) +
+				"13. Use with the GNU Affero General Public License, version 3.\n",
+			want: "GPL-3.0",
+		},
+		{
+			name: "AGPL-3.0",
+			text: "GNU AFFERO GENERAL PUBLIC LICENSE\nVersion 3, 19 November 2007\n",
+			want: "AGPL-3.0",
+		},
+		{
+			name: "LGPL-2.1",
+			text: "GNU LESSER GENERAL PUBLIC LICENSE\nVersion 2.1, February 1999\n",
+			want: "LGPL-2.1",
+		},
+		{
+			name: "BSD-3-Clause",
+			text: "Redistribution and use in source and binary forms, with or without modification, " +
+				"are permitted.\nNeither the name of the copyright holder nor the names of its contributors...",
+			want: "BSD-3-Clause",
+		},
+		{
+			name: "BSD-2-Clause",
+			text: "Redistribution and use in source and binary forms, with or without modification, are permitted.",
+			want: "BSD-2-Clause",
+		},
+		{
+			name: "ISC",
+			text: "Permission to use, copy, modify, and/or distribute this software for any purpose\n" +
+				"with or without fee is hereby granted, provided that the above copyright notice...",
+			want: "ISC",
+		},
+		{
+			name: "0BSD",
+			text: "Permission to use, copy, modify, and/or distribute this software for any purpose\n" +
+				"with or without fee is hereby granted.",
+			want: "0BSD",
+		},
+		{name: "unknown", text: "All rights reserved.", want: Unknown},
+		{name: "empty", text: "", want: Unknown},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := detectText(tt.text); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		name    string
+		files   map[string]string
+		license string
+		found   []string
+	}{
+		{name: "no license files", files: map[string]string{"README.md": mitText}, license: Unknown},
+		{name: "single file", files: map[string]string{"LICENSE": mitText}, license: "MIT", found: []string{"LICENSE"}},
+		{
+			name:    "many licenses",
+			files:   map[string]string{"LICENSE-MIT": mitText, "LICENSE-APACHE": apacheText},
+			license: "Apache-2.0 AND MIT",
+			found:   []string{"LICENSE-APACHE", "LICENSE-MIT"},
+		},
+		{
+			name:    "same license",
+			files:   map[string]string{"LICENSE": mitText, "COPYING.txt": mitText},
+			license: "MIT",
+			found:   []string{"COPYING.txt", "LICENSE"},
+		},
+		{
+			name:    "unknown license",
+			files:   map[string]string{"LICENSE": mitText, "LICENCE.md": "All rights reserved."},
+			license: Unknown,
+			found:   []string{"LICENCE.md", "LICENSE"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for name, content := range tt.files {
+				if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			license, found, err := Detect(dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if license != tt.license {
+				t.Errorf("license: got %q, want %q", license, tt.license)
+			}
+			if !reflect.DeepEqual(found, tt.found) {
+				t.Errorf("files: got %v, want %v", found, tt.found)
+			}
+		})
+	}
+}
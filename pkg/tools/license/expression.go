@@ -0,0 +1,154 @@
+package license
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// expression is the parsed SPDX license expression.
+type expression struct {
+	// ID is set for the single license, otherwise operands are combined using the operator.
+	ID       string
+	Operator string
+	Operands []expression
+}
+
+// satisfies checks if expression is satisfied by the set of allowed licenses. For OR any of the operands
+// must be allowed, for AND all of them.
+func (e expression) satisfies(allowed func(id string) bool) bool {
+	switch e.Operator {
+	case "OR":
+		for _, o := range e.Operands {
+			if o.satisfies(allowed) {
+				return true
+			}
+		}
+		return false
+	case "AND":
+		for _, o := range e.Operands {
+			if !o.satisfies(allowed) {
+				return false
+			}
+		}
+		return true
+	default:
+		return allowed(e.ID)
+	}
+}
+
+// parseExpression parses SPDX license expression. Exceptions added using WITH are ignored and
+// the legacy "/" separator used by some rust packages is treated as OR.
+func parseExpression(expr string) (expression, error) {
+	expr = strings.NewReplacer("(", " ( ", ")", " ) ", "/", " OR ").Replace(expr)
+	p := &expressionParser{tokens: strings.Fields(expr)}
+	e, err := p.parseOr()
+	if err != nil {
+		return expression{}, err
+	}
+	if p.pos != len(p.tokens) {
+		return expression{}, errors.Errorf("unexpected token '%s' in license expression", p.tokens[p.pos])
+	}
+	return e, nil
+}
+
+type expressionParser struct {
+	tokens []string
+	pos    int
+}
+
+func (p *expressionParser) parseOr() (expression, error) {
+	return p.parseBinary("OR", p.parseAnd)
+}
+
+func (p *expressionParser) parseAnd() (expression, error) {
+	return p.parseBinary("AND", p.parseWith)
+}
+
+func (p *expressionParser) parseBinary(operator string, parseOperand func() (expression, error)) (expression, error) {
+	e, err := parseOperand()
+	if err != nil {
+		return expression{}, err
+	}
+	operands := []expression{e}
+	for p.accept(operator) {
+		e, err := parseOperand()
+		if err != nil {
+			return expression{}, err
+		}
+		operands = append(operands, e)
+	}
+	if len(operands) == 1 {
+		return operands[0], nil
+	}
+	return expression{Operator: operator, Operands: operands}, nil
+}
+
+func (p *expressionParser) parseWith() (expression, error) {
+	e, err := p.parseAtom()
+	if err != nil {
+		return expression{}, err
+	}
+	if p.accept("WITH") {
+		if _, err := p.next(); err != nil {
+			return expression{}, err
+		}
+	}
+	return e, nil
+}
+
+func (p *expressionParser) parseAtom() (expression, error) {
+	if p.accept("(") {
+		e, err := p.parseOr()
+		if err != nil {
+			return expression{}, err
+		}
+		if !p.accept(")") {
+			return expression{}, errors.New("missing closing parenthesis in license expression")
+		}
+		return e, nil
+	}
+
+	token, err := p.next()
+	if err != nil {
+		return expression{}, err
+	}
+	if token == ")" || isOperator(token) {
+		return expression{}, errors.Errorf("unexpected token '%s' in license expression", token)
+	}
+	return expression{ID: normalizeID(token)}, nil
+}
+
+func (p *expressionParser) accept(token string) bool {
+	if p.pos < len(p.tokens) && strings.EqualFold(p.tokens[p.pos], token) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *expressionParser) next() (string, error) {
+	if p.pos == len(p.tokens) {
+		return "", errors.New("unexpected end of license expression")
+	}
+	token := p.tokens[p.pos]
+	p.pos++
+	return token, nil
+}
+
+func isOperator(token string) bool {
+	switch strings.ToUpper(token) {
+	case "AND", "OR", "WITH":
+		return true
+	default:
+		return false
+	}
+}
+
+// normalizeID removes version qualifiers from the license identifier, so e.g. GPL-2.0-only, GPL-2.0-or-later
+// and GPL-2.0+ are all matched by GPL-2.0.
+func normalizeID(id string) string {
+	id = strings.TrimSuffix(id, "+")
+	id = strings.TrimSuffix(id, "-only")
+	return strings.TrimSuffix(id, "-or-later")
+}
@@ -0,0 +1,148 @@
+package license
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseExpression(t *testing.T) {
+	tests := []struct {
+		expr    string
+		want    expression
+		wantErr bool
+	}{
+		{expr: "MIT", want: expression{ID: "MIT"}},
+		{expr: "GPL-2.0-or-later", want: expression{ID: "GPL-2.0"}},
+		{
+			expr: "MIT OR Apache-2.0",
+			want: expression{Operator: "OR", Operands: []expression{{ID: "MIT"}, {ID: "Apache-2.0"}}},
+		},
+		{
+			expr: "MIT/Apache-2.0",
+			want: expression{Operator: "OR", Operands: []expression{{ID: "MIT"}, {ID: "Apache-2.0"}}},
+		},
+		{
+			expr: "MIT AND BSD-3-Clause OR Apache-2.0",
+			want: expression{Operator: "OR", Operands: []expression{
+				{Operator: "AND", Operands: []expression{{ID: "MIT"}, {ID: "BSD-3-Clause"}}},
+				{ID: "Apache-2.0"},
+			}},
+		},
+		{
+			expr: "MIT and (BSD-3-Clause or Apache-2.0)",
+			want: expression{Operator: "AND", Operands: []expression{
+				{ID: "MIT"},
+				{Operator: "OR", Operands: []expression{{ID: "BSD-3-Clause"}, {ID: "Apache-2.0"}}},
+			}},
+		},
+		{
+			expr: "Apache-2.0 WITH LLVM-exception OR MIT",
+			want: expression{Operator: "OR", Operands: []expression{{ID: "Apache-2.0"}, {ID: "MIT"}}},
+		},
+		{expr: "", wantErr: true},
+		{expr: "MIT OR", wantErr: true},
+		{expr: "(MIT", wantErr: true},
+		{expr: "MIT)", wantErr: true},
+		{expr: "MIT Apache-2.0", wantErr: true},
+		{expr: "Apache-2.0 WITH", wantErr: true},
+		{expr: "AND MIT", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := parseExpression(tt.expr)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("parseExpression(%q): error expected", tt.expr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("parseExpression(%q): %v", tt.expr, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseExpression(%q) = %+v, want %+v", tt.expr, got, tt.want)
+		}
+	}
+}
+
+func TestNormalizeID(t *testing.T) {
+	tests := []struct {
+		id   string
+		want string
+	}{
+		{id: "MIT", want: "MIT"},
+		{id: "GPL-2.0-only", want: "GPL-2.0"},
+		{id: "GPL-2.0-or-later", want: "GPL-2.0"},
+		{id: "GPL-2.0+", want: "GPL-2.0"},
+		{id: "LGPL-2.1", want: "LGPL-2.1"},
+	}
+
+	for _, tt := range tests {
+		if got := normalizeID(tt.id); got != tt.want {
+			t.Errorf("normalizeID(%q) = %q, want %q", tt.id, got, tt.want)
+		}
+	}
+}
+
+func TestPolicyAllows(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  Policy
+		license string
+		want    bool
+	}{
+		{name: "empty policy", policy: Policy{}, license: "GPL-3.0", want: true},
+		{name: "allowed", policy: Policy{Allow: []string{"MIT"}}, license: "MIT", want: true},
+		{name: "not allowed", policy: Policy{Allow: []string{"MIT"}}, license: "Apache-2.0", want: false},
+		{name: "denied", policy: Policy{Deny: []string{"GPL-3.0"}}, license: "GPL-3.0-or-later", want: false},
+		{name: "deny wins", policy: Policy{Allow: []string{"MIT"}, Deny: []string{"MIT"}}, license: "MIT", want: false},
+		{
+			name:    "any of OR",
+			policy:  Policy{Allow: []string{"Apache-2.0"}},
+			license: "MIT OR Apache-2.0",
+			want:    true,
+		},
+		{
+			name:    "none of OR",
+			policy:  Policy{Allow: []string{"BSD-3-Clause"}},
+			license: "MIT OR Apache-2.0",
+			want:    false,
+		},
+		{
+			name:    "all of AND",
+			policy:  Policy{Allow: []string{"MIT", "Apache-2.0"}},
+			license: "MIT AND Apache-2.0",
+			want:    true,
+		},
+		{
+			name:    "part of AND",
+			policy:  Policy{Allow: []string{"MIT"}},
+			license: "MIT AND Apache-2.0",
+			want:    false,
+		},
+		{
+			name:    "exception ignored",
+			policy:  Policy{Allow: []string{"Apache-2.0"}},
+			license: "Apache-2.0 WITH LLVM-exception",
+			want:    true,
+		},
+		{name: "invalid expression", policy: Policy{}, license: "MIT OR", want: false},
+		{name: "deny-only policy, unknown license", policy: Policy{Deny: []string{"GPL-3.0"}}, license: Unknown},
+		{name: "empty policy, unknown license", policy: Policy{}, license: Unknown},
+		{
+			name:    "unknown license allowed",
+			policy:  Policy{Allow: []string{"MIT"}, AllowUnknown: true},
+			license: Unknown,
+			want:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.policy.Allows(tt.license); got != tt.want {
+				t.Errorf("got %t, want %t", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,247 @@
+package license
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/pkg/errors"
+	"github.com/samber/lo"
+	"go.uber.org/zap"
+
+	"github.com/outofforest/build/v2/pkg/helpers"
+	"github.com/outofforest/build/v2/pkg/types"
+	"github.com/outofforest/logger"
+	"github.com/outofforest/tools/pkg/tools/golang"
+	"github.com/outofforest/tools/pkg/tools/rust"
+)
+
+// Unknown is reported if license of the dependency can't be detected.
+const Unknown = "unknown"
+
+// Ecosystems of dependencies.
+const (
+	EcosystemGo   = "go"
+	EcosystemRust = "rust"
+)
+
+// Policy defines licenses accepted in dependencies.
+type Policy struct {
+	// Allow is the list of SPDX identifiers of allowed licenses. If empty, all the licenses which are not denied
+	// are allowed.
+	Allow []string
+
+	// Deny is the list of SPDX identifiers of denied licenses.
+	Deny []string
+
+	// AllowUnknown accepts dependencies with license which can't be detected.
+	AllowUnknown bool
+
+	// Exceptions is the list of dependencies, in the form of go module path or rust package name,
+	// accepted regardless of their license.
+	Exceptions []string
+}
+
+// AuditConfig is the configuration of license audit.
+type AuditConfig struct {
+	// Policy is the policy dependencies are verified against.
+	Policy Policy
+
+	// ReportPath is the path of the JSON report. If empty, report is not stored.
+	ReportPath string
+}
+
+// DefaultAuditConfig returns default configuration of license audit, accepting permissive licenses only.
+func DefaultAuditConfig() AuditConfig {
+	return AuditConfig{
+		Policy: Policy{
+			Allow: []string{
+				"0BSD",
+				"Apache-2.0",
+				"BSD-2-Clause",
+				"BSD-3-Clause",
+				"CC0-1.0",
+				"ISC",
+				"MIT",
+				"MPL-2.0",
+				"Unicode-3.0",
+				"Unicode-DFS-2016",
+				"Unlicense",
+				"Zlib",
+			},
+		},
+		ReportPath: "licenses.json",
+	}
+}
+
+// Entry is the dependency reported by the audit.
+type Entry struct {
+	Ecosystem string   `json:"ecosystem"`
+	Name      string   `json:"name"`
+	Version   string   `json:"version"`
+	License   string   `json:"license"`
+	Files     []string `json:"files,omitempty"`
+	Modules   []string `json:"modules"`
+	Allowed   bool     `json:"allowed"`
+}
+
+// Audit audits licenses of dependencies of go and rust modules using default config.
+func Audit(ctx context.Context, deps types.DepsFunc) error {
+	return audit(ctx, deps, DefaultAuditConfig())
+}
+
+// AuditWithConfig returns command auditing licenses of dependencies using provided config.
+func AuditWithConfig(config AuditConfig) types.CommandFunc {
+	return func(ctx context.Context, deps types.DepsFunc) error {
+		return audit(ctx, deps, config)
+	}
+}
+
+func audit(ctx context.Context, deps types.DepsFunc, config AuditConfig) error {
+	deps(golang.EnsureGo, rust.EnsureRust)
+
+	log := logger.Get(ctx)
+
+	entries := map[string]*Entry{}
+	addEntry := func(ecosystem, name, version, license string, files []string, module string) {
+		key := ecosystem + "/" + name + "@" + version
+		entry, exists := entries[key]
+		if !exists {
+			entry = &Entry{
+				Ecosystem: ecosystem,
+				Name:      name,
+				Version:   version,
+				License:   license,
+				Files:     files,
+				Allowed:   config.Policy.Allows(license) || lo.Contains(config.Policy.Exceptions, name),
+			}
+			entries[key] = entry
+		}
+		entry.Modules = lo.Uniq(append(entry.Modules, module))
+	}
+
+	if err := helpers.OnModule("go.mod", func(path string) error {
+		log.Info("Resolving go dependencies", zap.String("path", path))
+		goDeps, err := golang.Dependencies(ctx, path)
+		if err != nil {
+			return err
+		}
+		for _, dep := range goDeps {
+			license, files, err := Detect(dep.Dir)
+			if err != nil {
+				return err
+			}
+			addEntry(EcosystemGo, dep.Path, dep.Version, license, files, path)
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	if err := helpers.OnModule("Cargo.toml", func(path string) error {
+		log.Info("Resolving rust dependencies", zap.String("path", path))
+		rustDeps, err := rust.Dependencies(ctx, path)
+		if err != nil {
+			return err
+		}
+		for _, dep := range rustDeps {
+			license, files, err := Detect(dep.Dir)
+			if err != nil {
+				return err
+			}
+			if dep.License != "" {
+				license = dep.License
+			}
+			addEntry(EcosystemRust, dep.Name, dep.Version, license, files, path)
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	report := make([]Entry, 0, len(entries))
+	for _, entry := range entries {
+		report = append(report, *entry)
+	}
+	sort.Slice(report, func(i, j int) bool {
+		if report[i].Ecosystem != report[j].Ecosystem {
+			return report[i].Ecosystem < report[j].Ecosystem
+		}
+		if report[i].Name != report[j].Name {
+			return report[i].Name < report[j].Name
+		}
+		return report[i].Version < report[j].Version
+	})
+
+	if err := printReport(report); err != nil {
+		return err
+	}
+	if config.ReportPath != "" {
+		if err := storeReport(config.ReportPath, report); err != nil {
+			return err
+		}
+	}
+
+	violations := lo.Filter(report, func(entry Entry, _ int) bool {
+		return !entry.Allowed
+	})
+	if len(violations) > 0 {
+		return errors.Errorf("dependencies violate license policy:\n%s",
+			strings.Join(lo.Map(violations, func(entry Entry, _ int) string {
+				return fmt.Sprintf("%s %s@%s: %s", entry.Ecosystem, entry.Name, entry.Version, entry.License)
+			}), "\n"))
+	}
+	return nil
+}
+
+// Allows checks if license expression is accepted by the policy. Unknown license is accepted only if
+// AllowUnknown is set, regardless of the allowed and denied licenses.
+func (p Policy) Allows(license string) bool {
+	if license == Unknown {
+		return p.AllowUnknown
+	}
+	expr, err := parseExpression(license)
+	if err != nil {
+		return false
+	}
+	return expr.satisfies(func(id string) bool {
+		if lo.Contains(p.Deny, id) {
+			return false
+		}
+		return len(p.Allow) == 0 || lo.Contains(p.Allow, id)
+	})
+}
+
+func printReport(report []Entry) error {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	if _, err := fmt.Fprintln(w, "ECOSYSTEM\tDEPENDENCY\tVERSION\tLICENSE\tSTATUS"); err != nil {
+		return errors.WithStack(err)
+	}
+	for _, entry := range report {
+		status := "allowed"
+		if !entry.Allowed {
+			status = "DENIED"
+		}
+		if _, err := fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", entry.Ecosystem, entry.Name, entry.Version, entry.License,
+			status); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	return errors.WithStack(w.Flush())
+}
+
+func storeReport(path string, report []Entry) error {
+	content, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return errors.WithStack(err)
+	}
+	return errors.WithStack(os.WriteFile(path, append(content, '\n'), 0o600))
+}
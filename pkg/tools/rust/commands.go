@@ -1,6 +1,9 @@
 package rust
 
-import "github.com/outofforest/build/v2/pkg/types"
+import (
+	"github.com/outofforest/build/v2/pkg/types"
+	"github.com/outofforest/tools/pkg/tools/sarif"
+)
 
 // Commands is a set of commands useful for any rust environment.
 var Commands = map[string]types.Command{
@@ -8,6 +11,10 @@ var Commands = map[string]types.Command{
 		Description: "Lints rust code",
 		Fn:          Lint,
 	},
+	"lint/rust/sarif": {
+		Description: "Lints rust code and stores findings in " + sarif.DefaultPath,
+		Fn:          LintWithConfig(LintConfig{SARIFPath: sarif.DefaultPath}),
+	},
 	"test/rust": {
 		Description: "Runs rust unit tests",
 		Fn:          UnitTests,
@@ -0,0 +1,146 @@
+package rust
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os/exec"
+	"path/filepath"
+	"sort"
+
+	"github.com/pkg/errors"
+	"github.com/samber/lo"
+
+	"github.com/outofforest/build/v2/pkg/tools"
+	"github.com/outofforest/libexec"
+)
+
+// Dependency is the package compiled into the rust module.
+type Dependency struct {
+	// Name is the name of the package.
+	Name string
+
+	// Version is the version of the package.
+	Version string
+
+	// Source is the source the package is downloaded from.
+	Source string
+
+	// License is the SPDX license expression declared by the package.
+	License string
+
+	// Dir is the directory containing source code of the package.
+	Dir string
+}
+
+type cargoMetadata struct {
+	Packages []struct {
+		ID           string  `json:"id"`
+		Name         string  `json:"name"`
+		Version      string  `json:"version"`
+		Source       *string `json:"source"`
+		License      *string `json:"license"`
+		ManifestPath string  `json:"manifest_path"`
+	} `json:"packages"`
+	WorkspaceMembers []string `json:"workspace_members"`
+	Resolve          struct {
+		Nodes []struct {
+			ID   string `json:"id"`
+			Deps []struct {
+				Pkg      string         `json:"pkg"`
+				DepKinds []cargoDepKind `json:"dep_kinds"`
+			} `json:"deps"`
+		} `json:"nodes"`
+	} `json:"resolve"`
+}
+
+type cargoDepKind struct {
+	Kind *string `json:"kind"`
+}
+
+// Dependencies returns packages downloaded from external sources which are compiled into the module.
+// Development dependencies are not included.
+func Dependencies(ctx context.Context, modulePath string) ([]Dependency, error) {
+	_, deps, err := moduleDependencies(ctx, modulePath)
+	return deps, err
+}
+
+// moduleDependencies returns packages defined by the manifest of the module and packages downloaded
+// from external sources which are compiled into them. Development dependencies are not included.
+func moduleDependencies(ctx context.Context, modulePath string) ([]Dependency, []Dependency, error) {
+	buf := &bytes.Buffer{}
+	cmd := exec.Command(tools.Bin(ctx, "bin/cargo", tools.PlatformLocal), "metadata", "--format-version", "1")
+	cmd.Env = env(ctx)
+	cmd.Dir = modulePath
+	cmd.Stdout = buf
+	if err := libexec.Exec(ctx, cmd); err != nil {
+		return nil, nil, errors.Wrapf(err, "reading metadata failed in module '%s'", modulePath)
+	}
+
+	var metadata cargoMetadata
+	if err := json.Unmarshal(buf.Bytes(), &metadata); err != nil {
+		return nil, nil, errors.Wrapf(err, "parsing metadata failed in module '%s'", modulePath)
+	}
+
+	manifestPath := lo.Must(filepath.Abs(lo.Must(filepath.EvalSymlinks(filepath.Join(modulePath, "Cargo.toml")))))
+	var queue []string
+	roots := []Dependency{}
+	for _, pkg := range metadata.Packages {
+		if pkg.ManifestPath == manifestPath {
+			queue = append(queue, pkg.ID)
+			roots = append(roots, Dependency{
+				Name:    pkg.Name,
+				Version: pkg.Version,
+				License: lo.FromPtr(pkg.License),
+				Dir:     filepath.Dir(pkg.ManifestPath),
+			})
+		}
+	}
+	if len(queue) == 0 {
+		// Virtual manifest of the workspace.
+		queue = append(queue, metadata.WorkspaceMembers...)
+	}
+
+	graph := map[string][]string{}
+	for _, node := range metadata.Resolve.Nodes {
+		for _, dep := range node.Deps {
+			if lo.ContainsBy(dep.DepKinds, func(kind cargoDepKind) bool {
+				return kind.Kind == nil || *kind.Kind != "dev"
+			}) {
+				graph[node.ID] = append(graph[node.ID], dep.Pkg)
+			}
+		}
+	}
+
+	visited := map[string]bool{}
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		if visited[id] {
+			continue
+		}
+		visited[id] = true
+		queue = append(queue, graph[id]...)
+	}
+
+	result := []Dependency{}
+	for _, pkg := range metadata.Packages {
+		if !visited[pkg.ID] || pkg.Source == nil {
+			continue
+		}
+		result = append(result, Dependency{
+			Name:    pkg.Name,
+			Version: pkg.Version,
+			Source:  *pkg.Source,
+			License: lo.FromPtr(pkg.License),
+			Dir:     filepath.Dir(pkg.ManifestPath),
+		})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Name != result[j].Name {
+			return result[i].Name < result[j].Name
+		}
+		return result[i].Version < result[j].Version
+	})
+	return roots, result, nil
+}
@@ -0,0 +1,159 @@
+package rust
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/outofforest/build/v2/pkg/tools"
+	"github.com/outofforest/libexec"
+	"github.com/outofforest/tools/pkg/tools/sarif"
+)
+
+type clippyMessage struct {
+	Reason  string `json:"reason"`
+	Message struct {
+		Rendered string `json:"rendered"`
+		Message  string `json:"message"`
+		Level    string `json:"level"`
+		Code     *struct {
+			Code string `json:"code"`
+		} `json:"code"`
+		Spans []struct {
+			FileName    string `json:"file_name"`
+			LineStart   int    `json:"line_start"`
+			ColumnStart int    `json:"column_start"`
+			IsPrimary   bool   `json:"is_primary"`
+		} `json:"spans"`
+	} `json:"message"`
+}
+
+// clippyWriter parses json messages produced by clippy, passes rendered diagnostics to the output
+// and collects findings.
+type clippyWriter struct {
+	output        io.Writer
+	repoDir       string
+	workspaceDir  string
+	buf           []byte
+	findings      []sarif.Finding
+	seenPositions map[sarif.Finding]struct{}
+}
+
+func newClippyWriter(output io.Writer, repoDir, workspaceDir string) *clippyWriter {
+	return &clippyWriter{
+		output:        output,
+		repoDir:       repoDir,
+		workspaceDir:  workspaceDir,
+		seenPositions: map[sarif.Finding]struct{}{},
+	}
+}
+
+// Write parses the stream.
+func (w *clippyWriter) Write(p []byte) (int, error) {
+	w.buf = append(w.buf, p...)
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i < 0 {
+			return len(p), nil
+		}
+		line := w.buf[:i]
+		w.buf = w.buf[i+1:]
+		if err := w.processLine(line); err != nil {
+			return 0, err
+		}
+	}
+}
+
+// Flush processes the last line if it is not terminated by new line.
+func (w *clippyWriter) Flush() error {
+	if len(w.buf) == 0 {
+		return nil
+	}
+	line := w.buf
+	w.buf = nil
+	return w.processLine(line)
+}
+
+// Findings returns collected findings.
+func (w *clippyWriter) Findings() []sarif.Finding {
+	return w.findings
+}
+
+func (w *clippyWriter) processLine(line []byte) error {
+	var msg clippyMessage
+	if err := json.Unmarshal(line, &msg); err != nil {
+		// Not a json message, pass it as is.
+		_, err := w.output.Write(append(line, '\n'))
+		return errors.WithStack(err)
+	}
+	if msg.Reason != "compiler-message" {
+		return nil
+	}
+	if _, err := io.WriteString(w.output, msg.Message.Rendered); err != nil {
+		return errors.WithStack(err)
+	}
+
+	for _, span := range msg.Message.Spans {
+		if !span.IsPrimary {
+			continue
+		}
+
+		file := span.FileName
+		if !filepath.IsAbs(file) {
+			file = filepath.Join(w.workspaceDir, file)
+		}
+		file, err := filepath.Rel(w.repoDir, file)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+
+		level := sarif.LevelWarning
+		switch msg.Message.Level {
+		case "error":
+			level = sarif.LevelError
+		case "note", "help":
+			level = sarif.LevelNote
+		}
+		ruleID := msg.Message.Level
+		if msg.Message.Code != nil && msg.Message.Code.Code != "" {
+			ruleID = msg.Message.Code.Code
+		}
+
+		finding := sarif.Finding{
+			RuleID:  ruleID,
+			Level:   level,
+			Message: msg.Message.Message,
+			File:    file,
+			Line:    span.LineStart,
+			Column:  span.ColumnStart,
+		}
+		// The same diagnostic is reported for each target the code is compiled for.
+		if _, exists := w.seenPositions[finding]; exists {
+			break
+		}
+		w.seenPositions[finding] = struct{}{}
+		w.findings = append(w.findings, finding)
+		break
+	}
+	return nil
+}
+
+// workspaceDir returns the root directory of cargo workspace the module belongs to.
+func workspaceDir(ctx context.Context, path string) (string, error) {
+	buf := &bytes.Buffer{}
+	cmd := exec.Command(tools.Bin(ctx, "bin/cargo", tools.PlatformLocal), "locate-project", "--workspace",
+		"--message-format", "plain")
+	cmd.Env = env(ctx)
+	cmd.Dir = path
+	cmd.Stdout = buf
+	if err := libexec.Exec(ctx, cmd); err != nil {
+		return "", errors.Wrapf(err, "locating cargo workspace of module '%s' failed", path)
+	}
+	return filepath.Dir(strings.TrimSpace(buf.String())), nil
+}
@@ -19,6 +19,9 @@ import (
 	"github.com/outofforest/libexec"
 	"github.com/outofforest/logger"
 	"github.com/outofforest/tools/pkg/tools/docker"
+	"github.com/outofforest/tools/pkg/tools/junit"
+	"github.com/outofforest/tools/pkg/tools/sarif"
+	"github.com/outofforest/tools/pkg/tools/sbom"
 )
 
 // BuildConfig is the configuration for building binaries.
@@ -34,33 +37,92 @@ type BuildConfig struct {
 
 	// BinOutputPath is the path for compiled binary file.
 	BinOutputPath string
+
+	// SBOMFormats are the formats of SBOM documents stored next to the binary.
+	SBOMFormats []sbom.Format
 }
 
 // Build builds rust binary.
 func Build(ctx context.Context, deps types.DepsFunc, config BuildConfig) error {
+	var err error
 	if config.Platform.OS == tools.OSDocker {
-		return buildInDocker(ctx, deps, config)
+		err = buildInDocker(ctx, deps, config)
+	} else {
+		err = buildLocally(ctx, deps, config)
+	}
+	if err != nil || len(config.SBOMFormats) == 0 {
+		return err
 	}
-	return buildLocally(ctx, deps, config)
+
+	// SBOM is derived from cargo metadata, so cargo must be available locally even if binary is built in docker.
+	deps(EnsureRust)
+	return storeSBOM(ctx, config)
+}
+
+// LintConfig is the configuration for running linter.
+type LintConfig struct {
+	// SARIFPath is the path of the SARIF file findings from all the modules are stored in.
+	// If empty, SARIF file is not produced.
+	SARIFPath string
 }
 
 // Lint lints the rust code.
 func Lint(ctx context.Context, deps types.DepsFunc) error {
+	return lint(ctx, deps, LintConfig{})
+}
+
+// LintWithConfig returns command linting rust code using provided config.
+func LintWithConfig(config LintConfig) types.CommandFunc {
+	return func(ctx context.Context, deps types.DepsFunc) error {
+		return lint(ctx, deps, config)
+	}
+}
+
+func lint(ctx context.Context, deps types.DepsFunc, config LintConfig) error {
 	deps(EnsureRust)
 
 	log := logger.Get(ctx)
+	repoDir := lo.Must(filepath.Abs(lo.Must(filepath.EvalSymlinks("."))))
 
-	return helpers.OnModule("Cargo.toml", func(path string) error {
+	var findings []sarif.Finding
+	lintErr := helpers.OnModule("Cargo.toml", func(path string) error {
 		log.Info("Running linter", zap.String("path", path))
 		cmd := exec.Command(tools.Bin(ctx, "bin/cargo", tools.PlatformLocal), "clippy",
 			"--target-dir", targetDir(ctx))
 		cmd.Env = env(ctx)
 		cmd.Dir = path
-		if err := libexec.Exec(ctx, cmd); err != nil {
-			return errors.Wrapf(err, "linter errors found in module '%s'", path)
+
+		if config.SARIFPath == "" {
+			if err := libexec.Exec(ctx, cmd); err != nil {
+				return errors.Wrapf(err, "linter errors found in module '%s'", path)
+			}
+			return nil
+		}
+
+		wsDir, err := workspaceDir(ctx, path)
+		if err != nil {
+			return err
+		}
+		messages := newClippyWriter(os.Stderr, repoDir, lo.Must(filepath.EvalSymlinks(wsDir)))
+		cmd.Args = append(cmd.Args, "--message-format=json")
+		cmd.Stdout = messages
+		lintErr := libexec.Exec(ctx, cmd)
+		if err := messages.Flush(); err != nil {
+			return err
+		}
+		findings = append(findings, messages.Findings()...)
+		if lintErr != nil {
+			return errors.Wrapf(lintErr, "linter errors found in module '%s'", path)
 		}
 		return nil
 	})
+
+	if config.SARIFPath != "" {
+		if err := sarif.Store(config.SARIFPath, "clippy", findings); err != nil {
+			return err
+		}
+	}
+	return lintErr
 }
 
 // UnitTests runs rust unit tests in repository.
@@ -69,16 +131,33 @@ func UnitTests(ctx context.Context, deps types.DepsFunc) error {
 
 	log := logger.Get(ctx)
 
+	resultsDir := lo.Must(filepath.Abs(junit.ReportDir))
+	rootDir := filepath.Dir(lo.Must(filepath.Abs(lo.Must(filepath.EvalSymlinks(lo.Must(os.Getwd()))))))
 	return helpers.OnModule("Cargo.toml", func(path string) error {
 		path = lo.Must(filepath.Abs(lo.Must(filepath.EvalSymlinks(path))))
+		relPath, err := filepath.Rel(rootDir, path)
+		if err != nil {
+			return errors.WithStack(err)
+		}
 
 		log.Info("Running rust tests", zap.String("path", path))
+		results := newTestResultsParser()
+		stdout := results.StdoutWriter(os.Stdout)
+		stderr := results.StderrWriter(os.Stderr)
 		cmd := exec.Command(tools.Bin(ctx, "bin/cargo", tools.PlatformLocal), "test",
 			"--target-dir", targetDir(ctx))
 		cmd.Env = env(ctx)
 		cmd.Dir = path
-		if err := libexec.Exec(ctx, cmd); err != nil {
-			return errors.Wrapf(err, "unit tests failed in module '%s'", path)
+		cmd.Stdout = stdout
+		cmd.Stderr = stderr
+		testErr := libexec.Exec(ctx, cmd)
+		stdout.Flush()
+		stderr.Flush()
+		if err := junit.Store(resultsDir, strings.ReplaceAll(relPath, "/", "-"), results.Report(relPath)); err != nil {
+			return err
+		}
+		if testErr != nil {
+			return errors.Wrapf(testErr, "unit tests failed in module '%s'", path)
 		}
 		return nil
 	})
@@ -133,6 +212,7 @@ func buildInDocker(ctx context.Context, deps types.DepsFunc, config BuildConfig)
 	if err != nil {
 		return err
 	}
+	containerName := docker.ContainerName("outofforest-build-rust")
 	runArgs := []string{
 		"run", "--rm",
 		"--label", builddocker.LabelKey + "=" + builddocker.LabelValue,
@@ -140,7 +220,7 @@ func buildInDocker(ctx context.Context, deps types.DepsFunc, config BuildConfig)
 		"-v", envDir + ":" + envDir,
 		"--workdir", filepath.Join(srcDir, config.PackagePath),
 		"--user", fmt.Sprintf("%d:%d", os.Getuid(), os.Getgid()),
-		"--name", "outofforest-build-rust",
+		"--name", containerName,
 	}
 
 	for _, env := range envs {
@@ -159,7 +239,7 @@ func buildInDocker(ctx context.Context, deps types.DepsFunc, config BuildConfig)
 		zap.String("package", config.PackagePath),
 		zap.String("command", cmd.String()),
 	)
-	if err := libexec.Exec(ctx, cmd); err != nil {
+	if err := docker.RunContainer(ctx, containerName, cmd); err != nil {
 		return errors.Wrapf(err, "building package '%s' failed", config.PackagePath)
 	}
 
@@ -0,0 +1,137 @@
+package rust
+
+import (
+	"bufio"
+	"context"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/outofforest/tools/pkg/tools/sbom"
+)
+
+type cargoLockPackage struct {
+	Name     string
+	Version  string
+	Source   string
+	Checksum string
+}
+
+// storeSBOM stores SBOM documents of the binary. Components are the packages compiled into the package
+// being built, checksums are taken from Cargo.lock.
+func storeSBOM(ctx context.Context, config BuildConfig) error {
+	roots, deps, err := moduleDependencies(ctx, config.PackagePath)
+	if err != nil {
+		return err
+	}
+
+	lockFile, err := findCargoLock(config.PackagePath)
+	if err != nil {
+		return err
+	}
+	pkgs, err := parseCargoLock(lockFile)
+	if err != nil {
+		return err
+	}
+	checksums := map[string]string{}
+	for _, pkg := range pkgs {
+		checksums[pkg.Name+"@"+pkg.Version] = pkg.Checksum
+	}
+
+	var artifact sbom.Component
+	if len(roots) == 1 {
+		artifact = sbom.Component{
+			Version: roots[0].Version,
+			PURL:    cargoPURL(roots[0].Name, roots[0].Version),
+		}
+	}
+
+	components := make([]sbom.Component, 0, len(deps))
+	for _, dep := range deps {
+		components = append(components, sbom.Component{
+			Name:    dep.Name,
+			Version: dep.Version,
+			PURL:    cargoPURL(dep.Name, dep.Version),
+			SHA256:  checksums[dep.Name+"@"+dep.Version],
+		})
+	}
+
+	return sbom.Store(config.BinOutputPath, artifact, components, config.SBOMFormats)
+}
+
+func cargoPURL(name, version string) string {
+	return "pkg:cargo/" + name + "@" + version
+}
+
+// findCargoLock returns path to Cargo.lock file of the workspace the package belongs to.
+func findCargoLock(packagePath string) (string, error) {
+	dir, err := filepath.Abs(packagePath)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	for {
+		lockFile := filepath.Join(dir, "Cargo.lock")
+		if _, err := os.Stat(lockFile); err == nil {
+			return lockFile, nil
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", errors.Errorf("Cargo.lock not found for package '%s'", packagePath)
+		}
+		dir = parent
+	}
+}
+
+// parseCargoLock parses packages defined in Cargo.lock file.
+func parseCargoLock(lockFile string) ([]cargoLockPackage, error) {
+	f, err := os.Open(lockFile)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	defer f.Close()
+
+	var pkgs []cargoLockPackage
+	var pkg *cargoLockPackage
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "[") {
+			if line == "[[package]]" {
+				pkgs = append(pkgs, cargoLockPackage{})
+				pkg = &pkgs[len(pkgs)-1]
+			} else {
+				pkg = nil
+			}
+			continue
+		}
+		if pkg == nil {
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		value, err := strconv.Unquote(strings.TrimSpace(value))
+		if err != nil {
+			continue
+		}
+		switch strings.TrimSpace(key) {
+		case "name":
+			pkg.Name = value
+		case "version":
+			pkg.Version = value
+		case "source":
+			pkg.Source = value
+		case "checksum":
+			pkg.Checksum = value
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return pkgs, nil
+}
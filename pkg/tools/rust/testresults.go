@@ -0,0 +1,208 @@
+package rust
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/outofforest/tools/pkg/tools/junit"
+)
+
+var (
+	suiteNameRegExp   = regexp.MustCompile(`^\s*(?:Running (.+?)(?: \(.*\))?|(Doc-tests .+))$`)
+	suiteStartRegExp  = regexp.MustCompile(`^running \d+ tests?$`)
+	testResultRegExp  = regexp.MustCompile(`^test (.+) \.\.\. (ok|FAILED|ignored)(?:, (.*))?$`)
+	failureRegExp     = regexp.MustCompile(`^---- (.+) stdout ----$`)
+	suiteResultRegExp = regexp.MustCompile(`^test result: .* finished in ([0-9.]+)s$`)
+)
+
+// testResultsParser parses output of `cargo test` and collects test results. Names of the suites are printed
+// by cargo to stderr while results are printed by test binaries to stdout. Lines of both streams are not
+// ordered with respect to each other, so streams are parsed separately and suites are named in the order
+// the names were printed. Durations of individual tests are not reported by the stable toolchain,
+// so only time of each suite is available.
+type testResultsParser struct {
+	mu sync.Mutex
+
+	suiteNames  []string
+	suites      []junit.TestSuite
+	suite       *junit.TestSuite
+	failureName string
+	failures    map[string]*strings.Builder
+}
+
+func newTestResultsParser() *testResultsParser {
+	return &testResultsParser{}
+}
+
+// StdoutWriter returns writer passing the stdout stream to the output and parsing test results.
+func (p *testResultsParser) StdoutWriter(output io.Writer) *testResultsWriter {
+	return &testResultsWriter{
+		output:      output,
+		processLine: p.processResultLine,
+	}
+}
+
+// StderrWriter returns writer passing the stderr stream to the output and parsing names of the suites.
+func (p *testResultsParser) StderrWriter(output io.Writer) *testResultsWriter {
+	return &testResultsWriter{
+		output:      output,
+		processLine: p.processSuiteNameLine,
+	}
+}
+
+// Report returns collected results in JUnit format.
+func (p *testResultsParser) Report(name string) junit.TestSuites {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.finishSuite()
+
+	report := junit.TestSuites{Name: name}
+	var total float64
+	for i, suite := range p.suites {
+		if s, err := strconv.ParseFloat(suite.Time, 64); err == nil {
+			total += s
+		}
+		suite.Name = fmt.Sprintf("suite-%d", i+1)
+		if i < len(p.suiteNames) {
+			suite.Name = p.suiteNames[i]
+		}
+		suite.TestCases = append([]junit.TestCase{}, suite.TestCases...)
+		for j := range suite.TestCases {
+			suite.TestCases[j].ClassName = suite.Name
+		}
+		report.Suites = append(report.Suites, suite)
+	}
+	report.Time = junit.Seconds(time.Duration(total * float64(time.Second)))
+	return report
+}
+
+func (p *testResultsParser) processSuiteNameLine(line string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if match := suiteNameRegExp.FindStringSubmatch(strings.TrimRight(line, "\r\n")); match != nil {
+		p.suiteNames = append(p.suiteNames, match[1]+match[2])
+	}
+}
+
+func (p *testResultsParser) processResultLine(line string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	line = strings.TrimRight(line, "\r\n")
+
+	if p.failureName != "" {
+		if failureRegExp.MatchString(line) || line == "failures:" {
+			p.failureName = ""
+		} else {
+			p.failures[p.failureName].WriteString(line + "\n")
+			return
+		}
+	}
+
+	switch {
+	case suiteStartRegExp.MatchString(line):
+		p.finishSuite()
+		p.suite = &junit.TestSuite{}
+		p.failures = map[string]*strings.Builder{}
+	case p.suite == nil:
+	case testResultRegExp.MatchString(line):
+		match := testResultRegExp.FindStringSubmatch(line)
+		tc := junit.TestCase{
+			Name: match[1],
+			Time: junit.Seconds(0),
+		}
+		switch match[2] {
+		case "FAILED":
+			tc.Failure = &junit.Failure{Message: "test failed"}
+		case "ignored":
+			tc.Skipped = &junit.Skipped{Message: match[3]}
+		}
+		p.suite.TestCases = append(p.suite.TestCases, tc)
+	case failureRegExp.MatchString(line):
+		p.failureName = failureRegExp.FindStringSubmatch(line)[1]
+		p.failures[p.failureName] = &strings.Builder{}
+	case suiteResultRegExp.MatchString(line):
+		if s, err := strconv.ParseFloat(suiteResultRegExp.FindStringSubmatch(line)[1], 64); err == nil {
+			p.suite.Time = junit.Seconds(time.Duration(s * float64(time.Second)))
+		}
+		p.finishSuite()
+	}
+}
+
+func (p *testResultsParser) finishSuite() {
+	if p.suite == nil {
+		return
+	}
+	for i, tc := range p.suite.TestCases {
+		if tc.Failure == nil {
+			continue
+		}
+		if output, exists := p.failures[tc.Name]; exists {
+			tc.Failure.Output = output.String()
+			if message := panicMessage(output.String()); message != "" {
+				tc.Failure.Message = message
+			}
+			p.suite.TestCases[i] = tc
+		}
+	}
+	if p.suite.Time == "" {
+		p.suite.Time = junit.Seconds(0)
+	}
+	p.suites = append(p.suites, *p.suite)
+	p.suite = nil
+	p.failureName = ""
+}
+
+// panicMessage returns the message of the panic reported by failed test.
+func panicMessage(output string) string {
+	lines := strings.Split(output, "\n")
+	for i, line := range lines {
+		if !strings.Contains(line, "panicked at") {
+			continue
+		}
+		if i+1 < len(lines) && strings.TrimSpace(lines[i+1]) != "" {
+			return strings.TrimSpace(lines[i+1])
+		}
+		return strings.TrimSpace(line)
+	}
+	return ""
+}
+
+// testResultsWriter passes the stream to the output and feeds the parser with complete lines.
+type testResultsWriter struct {
+	output      io.Writer
+	processLine func(line string)
+	buf         []byte
+}
+
+// Write processes the stream.
+func (w *testResultsWriter) Write(p []byte) (int, error) {
+	if _, err := w.output.Write(p); err != nil {
+		return 0, err
+	}
+	w.buf = append(w.buf, p...)
+	for {
+		pos := bytes.IndexByte(w.buf, '\n')
+		if pos < 0 {
+			return len(p), nil
+		}
+		w.processLine(string(w.buf[:pos+1]))
+		w.buf = w.buf[pos+1:]
+	}
+}
+
+// Flush processes remaining incomplete line.
+func (w *testResultsWriter) Flush() {
+	if len(w.buf) > 0 {
+		w.processLine(string(w.buf))
+		w.buf = nil
+	}
+}
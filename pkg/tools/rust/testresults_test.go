@@ -0,0 +1,178 @@
+package rust
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/outofforest/tools/pkg/tools/junit"
+)
+
+func TestTestResultsParser(t *testing.T) {
+	tests := []struct {
+		name   string
+		stdout string
+		stderr string
+		report junit.TestSuites
+	}{
+		{
+			name: "unit tests and doc tests",
+			stderr: `   Compiling crate v0.1.0
+     Running unittests src/lib.rs (target/debug/deps/crate-0123456789abcdef)
+   Doc-tests crate
+`,
+			stdout: `
+running 3 tests
+test tests::ok ... ok
+test tests::slow ... ignored, takes too long
+test tests::bad ... FAILED
+
+failures:
+
+---- tests::bad stdout ----
+thread 'tests::bad' panicked at src/lib.rs:10:9:
+assertion failed: false
+note: run with ` + "`RUST_BACKTRACE=1`" + ` environment variable to display a backtrace
+
+
+failures:
+    tests::bad
+
+test result: FAILED. 1 passed; 1 failed; 1 ignored; 0 measured; 0 filtered out; finished in 0.25s
+
+
+running 1 test
+test src/lib.rs - add (line 3) ... ok
+
+test result: ok. 1 passed; 0 failed; 0 ignored; 0 measured; 0 filtered out; finished in 1.50s
+`,
+			report: junit.TestSuites{
+				Name: "crate",
+				Time: "1.750",
+				Suites: []junit.TestSuite{
+					{
+						Name: "unittests src/lib.rs",
+						Time: "0.250",
+						TestCases: []junit.TestCase{
+							{Name: "tests::ok", ClassName: "unittests src/lib.rs", Time: "0.000"},
+							{
+								Name:      "tests::slow",
+								ClassName: "unittests src/lib.rs",
+								Time:      "0.000",
+								Skipped:   &junit.Skipped{Message: "takes too long"},
+							},
+							{
+								Name:      "tests::bad",
+								ClassName: "unittests src/lib.rs",
+								Time:      "0.000",
+								Failure: &junit.Failure{
+									Message: "assertion failed: false",
+									Output: "thread 'tests::bad' panicked at src/lib.rs:10:9:\n" +
+										"assertion failed: false\n" +
+										"note: run with `RUST_BACKTRACE=1` environment variable to display a backtrace\n" +
+										"\n\n",
+								},
+							},
+						},
+					},
+					{
+						Name: "Doc-tests crate",
+						Time: "1.500",
+						TestCases: []junit.TestCase{
+							{Name: "src/lib.rs - add (line 3)", ClassName: "Doc-tests crate", Time: "0.000"},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "interrupted suite",
+			stdout: `running 1 test
+test hangs ... FAILED
+`,
+			report: junit.TestSuites{
+				Name: "crate",
+				Time: "0.000",
+				Suites: []junit.TestSuite{{
+					Name: "suite-1",
+					Time: "0.000",
+					TestCases: []junit.TestCase{{
+						Name:      "hangs",
+						ClassName: "suite-1",
+						Time:      "0.000",
+						Failure:   &junit.Failure{Message: "test failed"},
+					}},
+				}},
+			},
+		},
+		{
+			name:   "no tests",
+			stderr: "   Compiling crate v0.1.0\nerror: could not compile `crate`\n",
+			report: junit.TestSuites{
+				Name: "crate",
+				Time: "0.000",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parser := newTestResultsParser()
+
+			// Streams are written one after another to verify that results don't depend on their relative order.
+			stdout := &bytes.Buffer{}
+			writeStream(t, parser.StdoutWriter(stdout), tt.stdout)
+			stderr := &bytes.Buffer{}
+			writeStream(t, parser.StderrWriter(stderr), tt.stderr)
+
+			if stdout.String() != tt.stdout {
+				t.Errorf("stdout: got %q, want %q", stdout.String(), tt.stdout)
+			}
+			if stderr.String() != tt.stderr {
+				t.Errorf("stderr: got %q, want %q", stderr.String(), tt.stderr)
+			}
+			if report := parser.Report("crate"); !reflect.DeepEqual(report, tt.report) {
+				t.Errorf("report: got %+v, want %+v", report, tt.report)
+			}
+		})
+	}
+}
+
+// writeStream writes the stream in small chunks to verify that lines split between writes are handled.
+func writeStream(t *testing.T, w *testResultsWriter, stream string) {
+	t.Helper()
+
+	data := []byte(stream)
+	for len(data) > 0 {
+		n := min(len(data), 5)
+		if _, err := w.Write(data[:n]); err != nil {
+			t.Fatal(err)
+		}
+		data = data[n:]
+	}
+	w.Flush()
+}
+
+func TestPanicMessage(t *testing.T) {
+	tests := []struct {
+		output string
+		want   string
+	}{
+		{output: "", want: ""},
+		{output: "some output\n", want: ""},
+		{
+			output: "thread 'a' panicked at src/lib.rs:1:1:\nindex out of bounds\n",
+			want:   "index out of bounds",
+		},
+		{
+			output: "thread 'a' panicked at 'explicit panic', src/lib.rs:1:1\n",
+			want:   "thread 'a' panicked at 'explicit panic', src/lib.rs:1:1",
+		},
+	}
+
+	for _, tt := range tests {
+		if got := panicMessage(tt.output); got != tt.want {
+			t.Errorf("panicMessage(%q) = %q, want %q", tt.output, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,170 @@
+package sarif
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/pkg/errors"
+	"github.com/samber/lo"
+)
+
+// DefaultPath is the default path of SARIF file.
+const DefaultPath = "lint.sarif"
+
+const (
+	version = "2.1.0"
+	schema  = "https://json.schemastore.org/sarif-2.1.0.json"
+)
+
+// Levels of findings.
+const (
+	LevelError   = "error"
+	LevelWarning = "warning"
+	LevelNote    = "note"
+)
+
+// Finding is the issue reported by the tool.
+type Finding struct {
+	// RuleID is the identifier of the rule which has been violated.
+	RuleID string
+
+	// Level is the level of the finding.
+	Level string
+
+	// Message describes the finding.
+	Message string
+
+	// File is the path to the file, relative to the repository root.
+	File string
+
+	// Line is the line number the finding starts at.
+	Line int
+
+	// Column is the column number the finding starts at.
+	Column int
+}
+
+type log struct {
+	Version string `json:"version"`
+	Schema  string `json:"$schema"`
+	Runs    []run  `json:"runs"`
+}
+
+type run struct {
+	Tool    tool     `json:"tool"`
+	Results []result `json:"results"`
+}
+
+type tool struct {
+	Driver driver `json:"driver"`
+}
+
+type driver struct {
+	Name  string `json:"name"`
+	Rules []rule `json:"rules,omitempty"`
+}
+
+type rule struct {
+	ID string `json:"id"`
+}
+
+type result struct {
+	RuleID    string     `json:"ruleId"`
+	Level     string     `json:"level"`
+	Message   message    `json:"message"`
+	Locations []location `json:"locations"`
+}
+
+type message struct {
+	Text string `json:"text"`
+}
+
+type location struct {
+	PhysicalLocation physicalLocation `json:"physicalLocation"`
+}
+
+type physicalLocation struct {
+	ArtifactLocation artifactLocation `json:"artifactLocation"`
+	Region           region           `json:"region"`
+}
+
+type artifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type region struct {
+	StartLine   int `json:"startLine,omitempty"`
+	StartColumn int `json:"startColumn,omitempty"`
+}
+
+// Store stores findings of the tool in the SARIF file. If file already exists, results of the tool are replaced
+// and results of other tools are preserved, so findings of many tools might be merged into single file.
+func Store(path, toolName string, findings []Finding) error {
+	l := log{
+		Version: version,
+		Schema:  schema,
+	}
+
+	content, err := os.ReadFile(path)
+	switch {
+	case err == nil:
+		if err := json.Unmarshal(content, &l); err != nil {
+			return errors.Wrapf(err, "parsing SARIF file '%s' failed", path)
+		}
+	case os.IsNotExist(err):
+	default:
+		return errors.WithStack(err)
+	}
+
+	sort.SliceStable(findings, func(i, j int) bool {
+		if findings[i].File != findings[j].File {
+			return findings[i].File < findings[j].File
+		}
+		if findings[i].Line != findings[j].Line {
+			return findings[i].Line < findings[j].Line
+		}
+		return findings[i].Column < findings[j].Column
+	})
+
+	r := run{
+		Tool: tool{
+			Driver: driver{Name: toolName},
+		},
+		Results: make([]result, 0, len(findings)),
+	}
+	ruleIDs := make([]string, 0, len(findings))
+	for _, f := range findings {
+		ruleIDs = append(ruleIDs, f.RuleID)
+		r.Results = append(r.Results, result{
+			RuleID:  f.RuleID,
+			Level:   f.Level,
+			Message: message{Text: f.Message},
+			Locations: []location{{
+				PhysicalLocation: physicalLocation{
+					ArtifactLocation: artifactLocation{URI: filepath.ToSlash(f.File)},
+					Region: region{
+						StartLine:   f.Line,
+						StartColumn: f.Column,
+					},
+				},
+			}},
+		})
+	}
+	ruleIDs = lo.Uniq(ruleIDs)
+	sort.Strings(ruleIDs)
+	for _, id := range ruleIDs {
+		r.Tool.Driver.Rules = append(r.Tool.Driver.Rules, rule{ID: id})
+	}
+
+	l.Runs = append(lo.Filter(l.Runs, func(r run, _ int) bool {
+		return r.Tool.Driver.Name != toolName
+	}), r)
+
+	content, err = json.MarshalIndent(l, "", "  ")
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	return errors.WithStack(os.WriteFile(path, content, 0o600))
+}
@@ -0,0 +1,236 @@
+package sbom
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Format is the format of SBOM document.
+type Format string
+
+// Supported formats.
+const (
+	FormatSPDX      Format = "spdx"
+	FormatCycloneDX Format = "cyclonedx"
+)
+
+// Suffixes appended to the artifact path to get the path of SBOM document.
+const (
+	SPDXSuffix      = ".spdx.json"
+	CycloneDXSuffix = ".cdx.json"
+)
+
+const creator = "outofforest-build"
+
+// Component is the software component.
+type Component struct {
+	// Name is the name of the component.
+	Name string
+
+	// Version is the version of the component.
+	Version string
+
+	// PURL is the package URL identifying the component.
+	PURL string
+
+	// SHA256 is the checksum of the component, if known.
+	SHA256 string
+}
+
+// Store stores SBOM documents of the artifact, in requested formats, next to the artifact file.
+// Checksum of the artifact is computed and included in the documents.
+func Store(artifactPath string, artifact Component, components []Component, formats []Format) error {
+	checksum, err := checksum(artifactPath)
+	if err != nil {
+		return err
+	}
+	artifact.SHA256 = checksum
+	if artifact.Name == "" {
+		artifact.Name = filepath.Base(artifactPath)
+	}
+
+	created, err := creationTime()
+	if err != nil {
+		return err
+	}
+
+	for _, format := range formats {
+		var doc any
+		var path string
+		switch format {
+		case FormatSPDX:
+			doc = spdxDocument(artifact, components, created)
+			path = artifactPath + SPDXSuffix
+		case FormatCycloneDX:
+			doc = cycloneDXDocument(artifact, components, created)
+			path = artifactPath + CycloneDXSuffix
+		default:
+			return errors.Errorf("unsupported SBOM format '%s'", format)
+		}
+
+		content, err := json.MarshalIndent(doc, "", "  ")
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		if err := os.WriteFile(path, append(content, '\n'), 0o600); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	return nil
+}
+
+func spdxDocument(artifact Component, components []Component, created time.Time) map[string]any {
+	packages := []map[string]any{spdxPackage("SPDXRef-Artifact", artifact)}
+	relationships := []map[string]any{{
+		"spdxElementId":      "SPDXRef-DOCUMENT",
+		"relationshipType":   "DESCRIBES",
+		"relatedSpdxElement": "SPDXRef-Artifact",
+	}}
+	for i, c := range components {
+		id := "SPDXRef-Package-" + strconv.Itoa(i+1)
+		packages = append(packages, spdxPackage(id, c))
+		relationships = append(relationships, map[string]any{
+			"spdxElementId":      "SPDXRef-Artifact",
+			"relationshipType":   "CONTAINS",
+			"relatedSpdxElement": id,
+		})
+	}
+
+	return map[string]any{
+		"spdxVersion":       "SPDX-2.3",
+		"dataLicense":       "CC0-1.0",
+		"SPDXID":            "SPDXRef-DOCUMENT",
+		"name":              artifact.Name,
+		"documentNamespace": "https://spdx.org/spdxdocs/" + artifact.Name + "-" + artifact.SHA256,
+		"creationInfo": map[string]any{
+			"created":  created.Format(time.RFC3339),
+			"creators": []string{"Tool: " + creator},
+		},
+		"packages":      packages,
+		"relationships": relationships,
+	}
+}
+
+func spdxPackage(id string, c Component) map[string]any {
+	p := map[string]any{
+		"SPDXID":           id,
+		"name":             c.Name,
+		"downloadLocation": "NOASSERTION",
+		"filesAnalyzed":    false,
+		"licenseConcluded": "NOASSERTION",
+		"licenseDeclared":  "NOASSERTION",
+		"copyrightText":    "NOASSERTION",
+	}
+	if c.Version != "" {
+		p["versionInfo"] = c.Version
+	}
+	if c.SHA256 != "" {
+		p["checksums"] = []map[string]any{{"algorithm": "SHA256", "checksumValue": c.SHA256}}
+	}
+	if c.PURL != "" {
+		p["externalRefs"] = []map[string]any{{
+			"referenceCategory": "PACKAGE-MANAGER",
+			"referenceType":     "purl",
+			"referenceLocator":  c.PURL,
+		}}
+	}
+	return p
+}
+
+func cycloneDXDocument(artifact Component, components []Component, created time.Time) map[string]any {
+	artifactComponent := cycloneDXComponent("application", artifact)
+	artifactComponent["bom-ref"] = "artifact"
+
+	cdxComponents := make([]map[string]any, 0, len(components))
+	refs := make([]string, 0, len(components))
+	for i, c := range components {
+		component := cycloneDXComponent("library", c)
+		ref := c.PURL
+		if ref == "" {
+			ref = "component-" + strconv.Itoa(i+1)
+		}
+		component["bom-ref"] = ref
+		cdxComponents = append(cdxComponents, component)
+		refs = append(refs, ref)
+	}
+
+	return map[string]any{
+		"bomFormat":    "CycloneDX",
+		"specVersion":  "1.5",
+		"serialNumber": "urn:uuid:" + uuid(artifact.SHA256),
+		"version":      1,
+		"metadata": map[string]any{
+			"timestamp": created.Format(time.RFC3339),
+			"tools": map[string]any{
+				"components": []map[string]any{{"type": "application", "name": creator}},
+			},
+			"component": artifactComponent,
+		},
+		"components": cdxComponents,
+		"dependencies": []map[string]any{{
+			"ref":       "artifact",
+			"dependsOn": refs,
+		}},
+	}
+}
+
+func cycloneDXComponent(componentType string, c Component) map[string]any {
+	component := map[string]any{
+		"type": componentType,
+		"name": c.Name,
+	}
+	if c.Version != "" {
+		component["version"] = c.Version
+	}
+	if c.PURL != "" {
+		component["purl"] = c.PURL
+	}
+	if c.SHA256 != "" {
+		component["hashes"] = []map[string]any{{"alg": "SHA-256", "content": c.SHA256}}
+	}
+	return component
+}
+
+// creationTime returns the time of document creation. SOURCE_DATE_EPOCH is respected to keep documents
+// reproducible.
+func creationTime() (time.Time, error) {
+	if epoch := os.Getenv("SOURCE_DATE_EPOCH"); epoch != "" {
+		seconds, err := strconv.ParseInt(epoch, 10, 64)
+		if err != nil {
+			return time.Time{}, errors.Wrapf(err, "invalid SOURCE_DATE_EPOCH '%s'", epoch)
+		}
+		return time.Unix(seconds, 0).UTC(), nil
+	}
+	return time.Now().UTC(), nil
+}
+
+// uuid derives UUID from the checksum of the artifact, so the same artifact always gets the same serial number.
+func uuid(checksum string) string {
+	h := sha256.Sum256([]byte(checksum))
+	h[6] = (h[6] & 0x0f) | 0x50
+	h[8] = (h[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", h[0:4], h[4:6], h[6:8], h[8:10], h[10:16])
+}
+
+func checksum(file string) (string, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	defer f.Close()
+
+	hasher := sha256.New()
+	if _, err := io.Copy(hasher, f); err != nil {
+		return "", errors.WithStack(err)
+	}
+	return hex.EncodeToString(hasher.Sum(nil)), nil
+}